@@ -12,7 +12,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"sort"
@@ -111,6 +110,29 @@ type Client struct {
 	// User agent used when communicating with the Alipay API.
 	UserAgent string
 
+	// RateLimiter 可选的客户端限流器，为nil时不限流
+	RateLimiter *RateLimiter
+
+	// CircuitBreaker 可选的按接口方法熔断器，为nil时不熔断
+	CircuitBreaker *CircuitBreaker
+
+	// Clock 返回当前时间，用于生成请求的timestamp参数，为nil时使用time.Now
+	Clock func() time.Time
+
+	// MaxUploadSize multipart请求中单个文件的大小上限，单位字节，小于等于0表示不限制。
+	// 文件大小可以预先获取时在发送前检查，否则在上传过程中检查。
+	MaxUploadSize int64
+
+	// Rand multipart分隔符使用的随机源，为nil时使用crypto/rand.Reader。
+	// 配合Clock使用可以让同样的multipart请求生成完全一致的分隔符。
+	Rand io.Reader
+
+	// PollBackoff WaitForBuild等轮询方法的间隔，为nil时使用DefaultBackoff
+	PollBackoff *Backoff
+
+	// SkipValidation 为true时NewRequest不调用biz的Validate，直接将参数交给支付宝校验
+	SkipValidation bool
+
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	App  *AppService
@@ -154,22 +176,13 @@ func NewClient(httpClient *http.Client, privateKey *rsa.PrivateKey, publicKey *r
 	return c
 }
 
-// File wrapped file content
-type File struct {
-	Name    string
-	Content io.Reader
-}
-
-// Read proxy Content Read
-func (f File) Read(p []byte) (n int, err error) {
-	return f.Content.Read(p)
-}
-
 // NewRequest creates an API request. A relative URL can be provided in urlStr,
 // in which case it is resolved relative to the BaseURL of the Client.
 // Relative URLs should always be specified without a preceding slash. If
 // specified, the value pointed to by body is JSON encoded and included as the
-// request body.
+// request body. If bizContent implements MultiRender or is a struct containing
+// *File fields, the fields are sent as form values and the files are streamed
+// as a multipart body instead of being buffered in memory.
 func (c *Client) NewRequest(method string, bizContent interface{}, setters ...ValueOptions) (*http.Request, error) {
 	var (
 		sign        string
@@ -177,6 +190,7 @@ func (c *Client) NewRequest(method string, bizContent interface{}, setters ...Va
 		buf         *bytes.Buffer
 		req         *http.Request
 		reader      io.Reader
+		body        *multipartBody
 		err         error
 	)
 	v := url.Values{}
@@ -185,28 +199,30 @@ func (c *Client) NewRequest(method string, bizContent interface{}, setters ...Va
 	v.Set("format", c.o.Format)
 	v.Set("charset", c.o.Charset)
 	v.Set("sign_type", c.o.SignType)
-	v.Set("timestamp", time.Now().Format(timeLayout))
+	v.Set("timestamp", c.now().In(Location).Format(timeLayout))
 	v.Set("version", c.o.Version)
 	for _, setter := range setters {
 		setter(v)
 	}
+	if validator, ok := bizContent.(Validator); ok && !c.SkipValidation && !isNilPointer(bizContent) {
+		if err = validator.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	if bizContent != nil {
+		var (
+			params map[string]string
+			files  map[string]io.Reader
+		)
 		render, ok := bizContent.(MultiRender)
 		if ok {
-			var b bytes.Buffer
-			w := multipart.NewWriter(&b)
-			for key, r := range render.MultipartParams() {
-				var fw io.Writer
-				if x, ok := r.(*File); ok {
-					if fw, err = w.CreateFormFile(key, x.Name); err != nil {
-						return nil, err
-					}
-				}
-				if _, err = io.Copy(fw, r); err != nil {
-					return nil, err
-				}
+			params, files = render.Params(), render.MultipartParams()
+		} else if ok = hasFileField(bizContent); ok {
+			if params, files, err = encodeForm(bizContent); err != nil {
+				return nil, err
 			}
-			params := render.Params()
+		}
+		if ok {
 			for key, val := range params {
 				v.Set(key, val)
 			}
@@ -215,15 +231,17 @@ func (c *Client) NewRequest(method string, bizContent interface{}, setters ...Va
 				return nil, err
 			}
 			v.Set("sign", sign)
-			for k := range v {
-				_ = w.WriteField(k, v.Get(k))
+			var boundary string
+			if boundary, err = c.boundary(); err != nil {
+				return nil, err
 			}
-			err = w.Close()
-			if err != nil {
+			if body, err = newMultipartBody(boundary, v, files, c.MaxUploadSize); err != nil {
+				return nil, err
+			}
+			if reader, err = body.open(); err != nil {
 				return nil, err
 			}
-			reader = &b
-			contentType = w.FormDataContentType()
+			contentType = body.contentType()
 		} else {
 			buf = &bytes.Buffer{}
 			enc := json.NewEncoder(buf)
@@ -254,6 +272,14 @@ func (c *Client) NewRequest(method string, bizContent interface{}, setters ...Va
 	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		req.ContentLength = body.contentLength()
+		req.GetBody = body.open
+	}
+	req = req.WithContext(context.WithValue(req.Context(), requestMetaKey{}, &requestMeta{
+		method:       method,
+		appAuthToken: v.Get("app_auth_token"),
+	}))
 	v = req.URL.Query()
 	v.Set("charset", c.o.Charset)
 	req.URL.RawQuery = v.Encode()
@@ -266,6 +292,46 @@ func (c *Client) NewRequest(method string, bizContent interface{}, setters ...Va
 
 }
 
+func (c *Client) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+func (c *Client) rand() io.Reader {
+	if c.Rand != nil {
+		return c.Rand
+	}
+	return rand.Reader
+}
+
+// boundary 使用Rand生成multipart分隔符
+func (c *Client) boundary() (string, error) {
+	var buf [30]byte
+	if _, err := io.ReadFull(c.rand(), buf[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", buf[:]), nil
+}
+
+// sortedKeys 返回排序后的map key，保证请求体的字段顺序固定
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case url.Values:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]io.Reader:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Sign 参数签名
 func (c *Client) Sign(values url.Values) (string, error) {
 	if c.PrivateKey == nil {
@@ -326,6 +392,15 @@ func (r *ErrorResponse) Error() string {
 		r.Response.StatusCode, r.Msg, r.Code, r.SubCode, r.SubMsg)
 }
 
+// requestMetaKey 请求元信息在context中的key
+type requestMetaKey struct{}
+
+// requestMeta 由NewRequest记录的请求元信息，供限流等中间逻辑使用
+type requestMeta struct {
+	method       string
+	appAuthToken string
+}
+
 func withContext(ctx context.Context, req *http.Request) *http.Request {
 	return req.WithContext(ctx)
 }
@@ -339,8 +414,10 @@ type Response struct {
 // JSON decoded and stored in the value pointed to by v, or returned as an
 // error if an API error has occurred. If v implements the io.Writer
 // interface, the raw response body will be written to v, without attempting to
-// first decode it. If rate limit is exceeded and reset time is in the future,
-// Do returns *RateLimitError immediately without making a network API call.
+// first decode it. If the client has a RateLimiter and req was created by
+// NewRequest, Do waits for the API method's rate limit before sending. If the
+// client has a CircuitBreaker and the API method's circuit is open, Do returns
+// *CircuitOpenError immediately without making a network API call.
 //
 // The provided ctx must be non-nil, if it is nil an error is returned. If it is canceled or times out,
 // ctx.Err() will be returned.
@@ -348,6 +425,18 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	if ctx == nil {
 		return nil, errors.New("context must be non-nil")
 	}
+	meta, _ := req.Context().Value(requestMetaKey{}).(*requestMeta)
+	if c.RateLimiter != nil && meta != nil {
+		if err := c.RateLimiter.Wait(ctx, meta.method, meta.appAuthToken); err != nil {
+			return nil, err
+		}
+	}
+	// 限流等待之后再占用半开状态的探测名额，避免等待期间其它请求被拒绝
+	if c.CircuitBreaker != nil && meta != nil {
+		if err := c.CircuitBreaker.allow(meta.method); err != nil {
+			return nil, err
+		}
+	}
 	req = withContext(ctx, req)
 
 	resp, err := c.client.Do(req)
@@ -356,9 +445,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 		// the context's error is probably more useful.
 		select {
 		case <-ctx.Done():
+			c.reportCircuit(meta, circuitIgnore)
 			return nil, ctx.Err()
 		default:
 		}
+		c.reportCircuit(meta, circuitFailure)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -366,6 +457,10 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	response := &Response{resp}
 
 	err = c.CheckResponse(resp)
+	c.reportCircuit(meta, responseOutcome(resp, err))
+	if c.RateLimiter != nil && meta != nil {
+		c.RateLimiter.report(meta.method, meta.appAuthToken, err)
+	}
 	if err != nil {
 		return response, err
 	}
@@ -387,6 +482,24 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 	return response, err
 }
 
+// reportCircuit 将请求结果记录到熔断器
+func (c *Client) reportCircuit(meta *requestMeta, outcome circuitOutcome) {
+	if c.CircuitBreaker != nil && meta != nil {
+		c.CircuitBreaker.report(meta.method, outcome)
+	}
+}
+
+// responseOutcome HTTP 5xx和支付宝系统错误计为失败
+func responseOutcome(resp *http.Response, err error) circuitOutcome {
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return circuitFailure
+	}
+	if e, ok := err.(*ErrorResponse); ok && isSystemError(e) {
+		return circuitFailure
+	}
+	return circuitSuccess
+}
+
 // CheckResponse 检查返回内容
 func (c *Client) CheckResponse(r *http.Response) error {
 	errorResponse := &ErrorResponse{Response: r}
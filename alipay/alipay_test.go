@@ -1,6 +1,7 @@
 package alipay
 
 import (
+	"bytes"
 	"context"
 	"crypto/rsa"
 	"crypto/x509"
@@ -13,6 +14,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func setup() (client *Client, mux *http.ServeMux, serverURL string, tearDown func()) {
@@ -214,3 +216,33 @@ func TestFormat(t *testing.T) {
 		t.Errorf("Charset got %v, want %v", got, want)
 	}
 }
+
+func TestClient_NewRequest_reproducible(t *testing.T) {
+	key := `MIICWwIBAAKBgQC4UcQm06Kz9OH8Q6l2wxSOt9BdObuuC1hJQrQNbkqHU7SM1aI4g156fbAoaEZdb7k2bQSyf6PNWYNS+cl9LPsggbYZ1ZapbqgEt39N4sMKOPUEwMco4P9ZQL6C2+1YfqUc4zZKCqiocgXy0tuV3kKWYleOM/Y+J/2PfAUtKF2p3wIDAQABAoGANAQnRgnNzdla+TUjGvf80jX/oH+NfpWHCc3AQFYSxFQUDPaxPB+exxS3ZP/gc7f23ewwOiuZT3dmf0Es4p2SFOQypacVFyzi4Dj/cvJGxze8Ek047jS5wc6tZiQHjPcmPB0i2/wAJt9ThINdBnSzKrjRhfWy1aRay7fNk1BTmAECQQDvuYRR9yGDifc4T8at2xvUbPKavDFNUx2SNq233A2+DESFa9w3ZirVjiKzLR4/d60Gt/n9j5PssP4syECrGIwBAkEAxNVKNLO44+e8otUPc//s+Uhwzp3ASNT2JkVv4kFO+mkaGErkGnySWmWSbvjziK3TFkYOAGFUzH2+6MPETv+13wJAJKIl/VyVq4NG2z0dsG2+V/z6Kfk+U4GzECf47hLbqsI3KmhsM68SNqZM2TK435wLPe6Zbk0lntMBVJiZgUv0AQJAO/BLgZL9CYHHArro0sUrb5nsqC6HoGYhcvQQJxEGMOESjjU4Ewy+MILfvaVX29Y7AnxgxSLehMsB+LWssPXTdwJAJjRaoDllB2eO5wXAuKZNqYzpI6T3tK7tNG51SDlwkv3WMzuihwkv/tys/pWcFtwJFimbL34e/4dpWB1sHxtA1Q==`
+	encodedKey, _ := base64.StdEncoding.DecodeString(key)
+	privateKey, _ := x509.ParsePKCS1PrivateKey(encodedKey)
+
+	body := func() string {
+		c := NewClient(nil, privateKey, nil, AppID("2016091100484533"))
+		c.Clock = func() time.Time { return time.Date(2020, 4, 19, 14, 41, 12, 0, time.UTC) }
+		c.Rand = bytes.NewReader(make([]byte, 1024))
+		req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{
+			AppName:   "小程序示例",
+			AppSlogan: "这是一个支付示例",
+			AppLogo:   &File{Name: "logo.png", Content: strings.NewReader("logo")},
+		})
+		if err != nil {
+			t.Fatalf("NewRequest returned unexcepted error: %v", err)
+		}
+		data, _ := ioutil.ReadAll(req.Body)
+		return string(data)
+	}
+
+	first, second := body(), body()
+	if first != second {
+		t.Errorf("NewRequest bodies differ:\n%v\n%v", first, second)
+	}
+	if !strings.Contains(first, "2020-04-19 22:41:12") {
+		t.Errorf("NewRequest body does not use Clock: %v", first)
+	}
+}
@@ -0,0 +1,55 @@
+package alipayfake
+
+import (
+	"context"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// App 可编程的alipay.AppAPI实现，记录每次调用；未设置对应的Func字段时返回空结果和nil错误
+type App struct {
+	recorder
+
+	CreateMemberFunc    func(ctx context.Context, biz *alipay.CreateAppMemberBiz, opts ...alipay.ValueOptions) error
+	DeleteMemberFunc    func(ctx context.Context, biz *alipay.DeleteMemberBiz, opts ...alipay.ValueOptions) error
+	QueryAppMembersFunc func(ctx context.Context, biz *alipay.QueryAppMembersBiz, opts ...alipay.ValueOptions) (*alipay.QueryAppMembersResp, error)
+	CreateAppQRCodeFunc func(ctx context.Context, biz *alipay.CreateAppQRCodeBiz, opts ...alipay.ValueOptions) (*alipay.CreateAppQRCodeResp, error)
+}
+
+var _ alipay.AppAPI = (*App)(nil)
+
+// CreateMember implements alipay.AppAPI.
+func (a *App) CreateMember(ctx context.Context, biz *alipay.CreateAppMemberBiz, opts ...alipay.ValueOptions) error {
+	a.record("CreateMember", biz, opts)
+	if a.CreateMemberFunc != nil {
+		return a.CreateMemberFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// DeleteMember implements alipay.AppAPI.
+func (a *App) DeleteMember(ctx context.Context, biz *alipay.DeleteMemberBiz, opts ...alipay.ValueOptions) error {
+	a.record("DeleteMember", biz, opts)
+	if a.DeleteMemberFunc != nil {
+		return a.DeleteMemberFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// QueryAppMembers implements alipay.AppAPI.
+func (a *App) QueryAppMembers(ctx context.Context, biz *alipay.QueryAppMembersBiz, opts ...alipay.ValueOptions) (*alipay.QueryAppMembersResp, error) {
+	a.record("QueryAppMembers", biz, opts)
+	if a.QueryAppMembersFunc != nil {
+		return a.QueryAppMembersFunc(ctx, biz, opts...)
+	}
+	return new(alipay.QueryAppMembersResp), nil
+}
+
+// CreateAppQRCode implements alipay.AppAPI.
+func (a *App) CreateAppQRCode(ctx context.Context, biz *alipay.CreateAppQRCodeBiz, opts ...alipay.ValueOptions) (*alipay.CreateAppQRCodeResp, error) {
+	a.record("CreateAppQRCode", biz, opts)
+	if a.CreateAppQRCodeFunc != nil {
+		return a.CreateAppQRCodeFunc(ctx, biz, opts...)
+	}
+	return new(alipay.CreateAppQRCodeResp), nil
+}
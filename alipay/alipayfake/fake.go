@@ -0,0 +1,61 @@
+// Package alipayfake 提供alipay.AppAPI和alipay.MiniAPI的可编程假实现
+//
+// 假实现会记录每次调用的方法名、业务参数和可选参数，通过设置对应的XxxFunc字段
+// 返回预先配置的结果，适合在不启动HTTP服务的情况下对依赖这些接口的业务代码做单元测试。
+package alipayfake
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// Call 一次方法调用记录
+type Call struct {
+	Method string      // 方法名，例如UploadVersion
+	Biz    interface{} // 业务参数，方法没有业务参数时为nil
+	Values url.Values  // ValueOptions设置的公共参数，例如app_auth_token
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, biz interface{}, opts []alipay.ValueOptions) {
+	values := url.Values{}
+	for _, opt := range opts {
+		opt(values)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Biz: biz, Values: values})
+}
+
+// Calls 返回所有调用记录
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo 返回指定方法的调用记录
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset 清空调用记录
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,120 @@
+package alipayfake
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// latestVersion 依赖alipay.MiniAPI的业务代码示例
+func latestVersion(ctx context.Context, mini alipay.MiniAPI, token string) (string, error) {
+	resp, err := mini.QueryVersionList(ctx, alipay.AppAuthToken(token))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.AppVersions) == 0 {
+		return "", nil
+	}
+	return resp.AppVersions[len(resp.AppVersions)-1], nil
+}
+
+func TestMini(t *testing.T) {
+	mini := &Mini{
+		QueryVersionListFunc: func(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.QueryVersionListResp, error) {
+			return &alipay.QueryVersionListResp{AppVersions: []string{"0.0.1", "0.0.2"}}, nil
+		},
+	}
+	got, err := latestVersion(context.Background(), mini, "token")
+	if err != nil {
+		t.Fatalf("latestVersion returned unexcepted error: %v", err)
+	}
+	if got != "0.0.2" {
+		t.Errorf("latestVersion got %v, want %v", got, "0.0.2")
+	}
+
+	calls := mini.CallsTo("QueryVersionList")
+	if len(calls) != 1 || calls[0].Values.Get("app_auth_token") != "token" {
+		t.Errorf("Mini.CallsTo got %+v, want one call with app_auth_token", calls)
+	}
+
+	biz := &alipay.OnlineVersionBiz{AppVersion: "0.0.2"}
+	if err := mini.OnlineVersion(context.Background(), biz); err != nil {
+		t.Errorf("Mini.OnlineVersion returned unexcepted error: %v", err)
+	}
+	if got := mini.Calls(); len(got) != 2 || !reflect.DeepEqual(got[1].Biz, biz) {
+		t.Errorf("Mini.Calls got %+v", got)
+	}
+
+	mini.Reset()
+	if got := mini.Calls(); len(got) != 0 {
+		t.Errorf("Mini.Calls after Reset got %+v", got)
+	}
+}
+
+func TestApp(t *testing.T) {
+	wantErr := errors.New("boom")
+	app := &App{
+		CreateMemberFunc: func(ctx context.Context, biz *alipay.CreateAppMemberBiz, opts ...alipay.ValueOptions) error {
+			return wantErr
+		},
+	}
+	var api alipay.AppAPI = app
+	if err := api.CreateMember(context.Background(), &alipay.CreateAppMemberBiz{LogonID: "test"}); err != wantErr {
+		t.Errorf("App.CreateMember got %v, want %v", err, wantErr)
+	}
+	resp, err := api.QueryAppMembers(context.Background(), &alipay.QueryAppMembersBiz{})
+	if err != nil || resp == nil {
+		t.Errorf("App.QueryAppMembers got %v, %v, want empty response", resp, err)
+	}
+}
+
+func TestMini_helpers(t *testing.T) {
+	statuses := []alipay.CreateStatus{alipay.CreateStatusBuilding, alipay.CreateStatusBuildFailed}
+	mini := &Mini{
+		QueryVersionListFunc: func(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.QueryVersionListResp, error) {
+			return &alipay.QueryVersionListResp{AppVersions: []string{"0.0.9", "0.0.10"}}, nil
+		},
+		QueryVersionBuildFunc: func(ctx context.Context, biz *alipay.QueryVersionBuildBiz, opts ...alipay.ValueOptions) (*alipay.QueryVersionBuildResp, error) {
+			status := statuses[0]
+			statuses = statuses[1:]
+			return &alipay.QueryVersionBuildResp{NeedRotation: "true", CreateStatus: status}, nil
+		},
+	}
+	ctx := context.Background()
+	if v, err := alipay.NextVersion(ctx, mini, alipay.BumpPatch); err != nil || v.String() != "0.0.11" {
+		t.Errorf("alipay.NextVersion got %v, %v, want 0.0.11", v, err)
+	}
+	_, err := alipay.WaitForBuild(ctx, mini, "0.0.11")
+	var buildErr *alipay.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Errorf("alipay.WaitForBuild got %v, want *alipay.BuildError", err)
+	}
+	if got := len(mini.CallsTo("QueryVersionBuild")); got != 2 {
+		t.Errorf("QueryVersionBuild calls got %v, want 2", got)
+	}
+}
+
+func TestMini_WatchVersion(t *testing.T) {
+	statuses := []alipay.VersionStatus{alipay.VersionStatusAuditing, alipay.VersionStatusAuditing, alipay.VersionStatusWaitRelease}
+	mini := &Mini{
+		QueryVersionDetailFunc: func(ctx context.Context, biz *alipay.QueryVersionDetailBiz, opts ...alipay.ValueOptions) (*alipay.VersionDetail, error) {
+			status := statuses[0]
+			statuses = statuses[1:]
+			return &alipay.VersionDetail{Status: status}, nil
+		},
+	}
+	var got []alipay.VersionStatus
+	for e := range alipay.WatchVersion(context.Background(), mini, "0.0.1", 0) {
+		got = append(got, e.To)
+	}
+	want := []alipay.VersionStatus{alipay.VersionStatusAuditing, alipay.VersionStatusWaitRelease}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("alipay.WatchVersion got %v, want %v", got, want)
+	}
+	if got := len(mini.CallsTo("QueryVersionDetail")); got != 3 {
+		t.Errorf("QueryVersionDetail calls got %v, want 3", got)
+	}
+}
@@ -0,0 +1,305 @@
+package alipayfake
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// fastBackoff 未设置Mini.Backoff时的轮询间隔
+var fastBackoff = alipay.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+// Mini 可编程的alipay.MiniAPI实现，记录每次调用；未设置对应的Func字段时返回空结果和nil错误
+//
+// alipay.WaitForBuild等辅助函数接受alipay.MiniAPI，可以直接在Mini上使用，它们调用的接口同样会被记录。
+type Mini struct {
+	recorder
+
+	// Backoff 辅助函数的轮询间隔，为nil时每毫秒轮询一次
+	Backoff *alipay.Backoff
+
+	QueryBaseInfoFunc             func(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.BaseInfo, error)
+	ModifyBaseInfoFunc            func(ctx context.Context, biz *alipay.ModifyBaseInfoBiz, opts ...alipay.ValueOptions) error
+	CreateSafeDomainFunc          func(ctx context.Context, biz *alipay.CreateSafeDomainBiz, opts ...alipay.ValueOptions) error
+	DetectRiskContentFunc         func(ctx context.Context, biz *alipay.DetectRiskContentBiz, opts ...alipay.ValueOptions) (*alipay.DetectRiskContentResp, error)
+	QueryTinyAppExistFunc         func(ctx context.Context, biz *alipay.QueryTinyAppExistBiz, opts ...alipay.ValueOptions) (*alipay.QueryTinyAppExistResp, error)
+	QueryCategoryFunc             func(ctx context.Context, biz *alipay.QueryCategoryBiz, opts ...alipay.ValueOptions) (*alipay.QueryCategoryResp, error)
+	CertifyIndividualBusinessFunc func(ctx context.Context, biz *alipay.CertifyIndividualBusinessBiz, opts ...alipay.ValueOptions) (*alipay.CertifyIndividualBusinessResp, error)
+	SyncContentFunc               func(ctx context.Context, biz *alipay.SyncContentBiz, opts ...alipay.ValueOptions) (*alipay.SyncContentResp, error)
+	CreateExperienceFunc          func(ctx context.Context, biz *alipay.CreateExperienceBiz, opts ...alipay.ValueOptions) error
+	QueryExperienceFunc           func(ctx context.Context, biz *alipay.QueryExperienceBiz, opts ...alipay.ValueOptions) (*alipay.ExperienceStatus, error)
+	CancelExperienceFunc          func(ctx context.Context, biz *alipay.CancelExperienceBiz, opts ...alipay.ValueOptions) error
+	BindQrCodeFunc                func(ctx context.Context, biz *alipay.BindQrCodeBiz, opts ...alipay.ValueOptions) (*alipay.BindQrCodeResp, error)
+	UnbindQrCodeFunc              func(ctx context.Context, biz *alipay.UnbindQrCodeBiz, opts ...alipay.ValueOptions) error
+	QueryTemplateUsageFunc        func(ctx context.Context, biz *alipay.QueryTemplateUsageBiz, opts ...alipay.ValueOptions) (*alipay.QueryTemplateUsageResp, error)
+	QueryVersionListFunc          func(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.QueryVersionListResp, error)
+	DeleteVersionFunc             func(ctx context.Context, biz *alipay.DeleteVersionBiz, opts ...alipay.ValueOptions) error
+	ApplyVersionAuditFunc         func(ctx context.Context, biz *alipay.ApplyVersionAuditBiz, opts ...alipay.ValueOptions) error
+	CancelVersionAuditFunc        func(ctx context.Context, biz *alipay.CancelVersionAuditBiz, opts ...alipay.ValueOptions) error
+	CancelVersionAuditedFunc      func(ctx context.Context, biz *alipay.CancelVersionAuditedBiz, opts ...alipay.ValueOptions) error
+	OnlineVersionFunc             func(ctx context.Context, biz *alipay.OnlineVersionBiz, opts ...alipay.ValueOptions) error
+	OfflineVersionFunc            func(ctx context.Context, biz *alipay.OfflineVersionBiz, opts ...alipay.ValueOptions) error
+	RollbackVersionFunc           func(ctx context.Context, biz *alipay.RollbackVersionBiz, opts ...alipay.ValueOptions) error
+	OnlineGrayVersionFunc         func(ctx context.Context, biz *alipay.OnlineGrayVersionBiz, opts ...alipay.ValueOptions) error
+	CancelGrayVersionFunc         func(ctx context.Context, biz *alipay.CancelGrayVersionBiz, opts ...alipay.ValueOptions) error
+	UploadVersionFunc             func(ctx context.Context, biz *alipay.UploadVersionBiz, opts ...alipay.ValueOptions) error
+	QueryVersionDetailFunc        func(ctx context.Context, biz *alipay.QueryVersionDetailBiz, opts ...alipay.ValueOptions) (*alipay.VersionDetail, error)
+	QueryVersionBuildFunc         func(ctx context.Context, biz *alipay.QueryVersionBuildBiz, opts ...alipay.ValueOptions) (*alipay.QueryVersionBuildResp, error)
+}
+
+var (
+	_ alipay.MiniAPI = (*Mini)(nil)
+	_ alipay.Poller  = (*Mini)(nil)
+)
+
+// PollBackoff implements alipay.Poller.
+func (m *Mini) PollBackoff() alipay.Backoff {
+	if m.Backoff != nil {
+		return *m.Backoff
+	}
+	return fastBackoff
+}
+
+// QueryBaseInfo implements alipay.MiniAPI.
+func (m *Mini) QueryBaseInfo(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.BaseInfo, error) {
+	m.record("QueryBaseInfo", nil, opts)
+	if m.QueryBaseInfoFunc != nil {
+		return m.QueryBaseInfoFunc(ctx, opts...)
+	}
+	return new(alipay.BaseInfo), nil
+}
+
+// ModifyBaseInfo implements alipay.MiniAPI.
+func (m *Mini) ModifyBaseInfo(ctx context.Context, biz *alipay.ModifyBaseInfoBiz, opts ...alipay.ValueOptions) error {
+	m.record("ModifyBaseInfo", biz, opts)
+	if m.ModifyBaseInfoFunc != nil {
+		return m.ModifyBaseInfoFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// CreateSafeDomain implements alipay.MiniAPI.
+func (m *Mini) CreateSafeDomain(ctx context.Context, biz *alipay.CreateSafeDomainBiz, opts ...alipay.ValueOptions) error {
+	m.record("CreateSafeDomain", biz, opts)
+	if m.CreateSafeDomainFunc != nil {
+		return m.CreateSafeDomainFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// DetectRiskContent implements alipay.MiniAPI.
+func (m *Mini) DetectRiskContent(ctx context.Context, biz *alipay.DetectRiskContentBiz, opts ...alipay.ValueOptions) (*alipay.DetectRiskContentResp, error) {
+	m.record("DetectRiskContent", biz, opts)
+	if m.DetectRiskContentFunc != nil {
+		return m.DetectRiskContentFunc(ctx, biz, opts...)
+	}
+	return new(alipay.DetectRiskContentResp), nil
+}
+
+// QueryTinyAppExist implements alipay.MiniAPI.
+func (m *Mini) QueryTinyAppExist(ctx context.Context, biz *alipay.QueryTinyAppExistBiz, opts ...alipay.ValueOptions) (*alipay.QueryTinyAppExistResp, error) {
+	m.record("QueryTinyAppExist", biz, opts)
+	if m.QueryTinyAppExistFunc != nil {
+		return m.QueryTinyAppExistFunc(ctx, biz, opts...)
+	}
+	return new(alipay.QueryTinyAppExistResp), nil
+}
+
+// QueryCategory implements alipay.MiniAPI.
+func (m *Mini) QueryCategory(ctx context.Context, biz *alipay.QueryCategoryBiz, opts ...alipay.ValueOptions) (*alipay.QueryCategoryResp, error) {
+	m.record("QueryCategory", biz, opts)
+	if m.QueryCategoryFunc != nil {
+		return m.QueryCategoryFunc(ctx, biz, opts...)
+	}
+	return new(alipay.QueryCategoryResp), nil
+}
+
+// CertifyIndividualBusiness implements alipay.MiniAPI.
+func (m *Mini) CertifyIndividualBusiness(ctx context.Context, biz *alipay.CertifyIndividualBusinessBiz, opts ...alipay.ValueOptions) (*alipay.CertifyIndividualBusinessResp, error) {
+	m.record("CertifyIndividualBusiness", biz, opts)
+	if m.CertifyIndividualBusinessFunc != nil {
+		return m.CertifyIndividualBusinessFunc(ctx, biz, opts...)
+	}
+	return new(alipay.CertifyIndividualBusinessResp), nil
+}
+
+// SyncContent implements alipay.MiniAPI.
+func (m *Mini) SyncContent(ctx context.Context, biz *alipay.SyncContentBiz, opts ...alipay.ValueOptions) (*alipay.SyncContentResp, error) {
+	m.record("SyncContent", biz, opts)
+	if m.SyncContentFunc != nil {
+		return m.SyncContentFunc(ctx, biz, opts...)
+	}
+	return new(alipay.SyncContentResp), nil
+}
+
+// CreateExperience implements alipay.MiniAPI.
+func (m *Mini) CreateExperience(ctx context.Context, biz *alipay.CreateExperienceBiz, opts ...alipay.ValueOptions) error {
+	m.record("CreateExperience", biz, opts)
+	if m.CreateExperienceFunc != nil {
+		return m.CreateExperienceFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// QueryExperience implements alipay.MiniAPI.
+func (m *Mini) QueryExperience(ctx context.Context, biz *alipay.QueryExperienceBiz, opts ...alipay.ValueOptions) (*alipay.ExperienceStatus, error) {
+	m.record("QueryExperience", biz, opts)
+	if m.QueryExperienceFunc != nil {
+		return m.QueryExperienceFunc(ctx, biz, opts...)
+	}
+	return new(alipay.ExperienceStatus), nil
+}
+
+// CancelExperience implements alipay.MiniAPI.
+func (m *Mini) CancelExperience(ctx context.Context, biz *alipay.CancelExperienceBiz, opts ...alipay.ValueOptions) error {
+	m.record("CancelExperience", biz, opts)
+	if m.CancelExperienceFunc != nil {
+		return m.CancelExperienceFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// BindQrCode implements alipay.MiniAPI.
+func (m *Mini) BindQrCode(ctx context.Context, biz *alipay.BindQrCodeBiz, opts ...alipay.ValueOptions) (*alipay.BindQrCodeResp, error) {
+	m.record("BindQrCode", biz, opts)
+	if m.BindQrCodeFunc != nil {
+		return m.BindQrCodeFunc(ctx, biz, opts...)
+	}
+	return new(alipay.BindQrCodeResp), nil
+}
+
+// UnbindQrCode implements alipay.MiniAPI.
+func (m *Mini) UnbindQrCode(ctx context.Context, biz *alipay.UnbindQrCodeBiz, opts ...alipay.ValueOptions) error {
+	m.record("UnbindQrCode", biz, opts)
+	if m.UnbindQrCodeFunc != nil {
+		return m.UnbindQrCodeFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// QueryTemplateUsage implements alipay.MiniAPI.
+func (m *Mini) QueryTemplateUsage(ctx context.Context, biz *alipay.QueryTemplateUsageBiz, opts ...alipay.ValueOptions) (*alipay.QueryTemplateUsageResp, error) {
+	m.record("QueryTemplateUsage", biz, opts)
+	if m.QueryTemplateUsageFunc != nil {
+		return m.QueryTemplateUsageFunc(ctx, biz, opts...)
+	}
+	return new(alipay.QueryTemplateUsageResp), nil
+}
+
+// QueryVersionList implements alipay.MiniAPI.
+func (m *Mini) QueryVersionList(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.QueryVersionListResp, error) {
+	m.record("QueryVersionList", nil, opts)
+	if m.QueryVersionListFunc != nil {
+		return m.QueryVersionListFunc(ctx, opts...)
+	}
+	return new(alipay.QueryVersionListResp), nil
+}
+
+// DeleteVersion implements alipay.MiniAPI.
+func (m *Mini) DeleteVersion(ctx context.Context, biz *alipay.DeleteVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("DeleteVersion", biz, opts)
+	if m.DeleteVersionFunc != nil {
+		return m.DeleteVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// ApplyVersionAudit implements alipay.MiniAPI.
+func (m *Mini) ApplyVersionAudit(ctx context.Context, biz *alipay.ApplyVersionAuditBiz, opts ...alipay.ValueOptions) error {
+	m.record("ApplyVersionAudit", biz, opts)
+	if m.ApplyVersionAuditFunc != nil {
+		return m.ApplyVersionAuditFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// CancelVersionAudit implements alipay.MiniAPI.
+func (m *Mini) CancelVersionAudit(ctx context.Context, biz *alipay.CancelVersionAuditBiz, opts ...alipay.ValueOptions) error {
+	m.record("CancelVersionAudit", biz, opts)
+	if m.CancelVersionAuditFunc != nil {
+		return m.CancelVersionAuditFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// CancelVersionAudited implements alipay.MiniAPI.
+func (m *Mini) CancelVersionAudited(ctx context.Context, biz *alipay.CancelVersionAuditedBiz, opts ...alipay.ValueOptions) error {
+	m.record("CancelVersionAudited", biz, opts)
+	if m.CancelVersionAuditedFunc != nil {
+		return m.CancelVersionAuditedFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// OnlineVersion implements alipay.MiniAPI.
+func (m *Mini) OnlineVersion(ctx context.Context, biz *alipay.OnlineVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("OnlineVersion", biz, opts)
+	if m.OnlineVersionFunc != nil {
+		return m.OnlineVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// OfflineVersion implements alipay.MiniAPI.
+func (m *Mini) OfflineVersion(ctx context.Context, biz *alipay.OfflineVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("OfflineVersion", biz, opts)
+	if m.OfflineVersionFunc != nil {
+		return m.OfflineVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// RollbackVersion implements alipay.MiniAPI.
+func (m *Mini) RollbackVersion(ctx context.Context, biz *alipay.RollbackVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("RollbackVersion", biz, opts)
+	if m.RollbackVersionFunc != nil {
+		return m.RollbackVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// OnlineGrayVersion implements alipay.MiniAPI.
+func (m *Mini) OnlineGrayVersion(ctx context.Context, biz *alipay.OnlineGrayVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("OnlineGrayVersion", biz, opts)
+	if m.OnlineGrayVersionFunc != nil {
+		return m.OnlineGrayVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// CancelGrayVersion implements alipay.MiniAPI.
+func (m *Mini) CancelGrayVersion(ctx context.Context, biz *alipay.CancelGrayVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("CancelGrayVersion", biz, opts)
+	if m.CancelGrayVersionFunc != nil {
+		return m.CancelGrayVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// UploadVersion implements alipay.MiniAPI.
+func (m *Mini) UploadVersion(ctx context.Context, biz *alipay.UploadVersionBiz, opts ...alipay.ValueOptions) error {
+	m.record("UploadVersion", biz, opts)
+	if m.UploadVersionFunc != nil {
+		return m.UploadVersionFunc(ctx, biz, opts...)
+	}
+	return nil
+}
+
+// QueryVersionDetail implements alipay.MiniAPI.
+func (m *Mini) QueryVersionDetail(ctx context.Context, biz *alipay.QueryVersionDetailBiz, opts ...alipay.ValueOptions) (*alipay.VersionDetail, error) {
+	m.record("QueryVersionDetail", biz, opts)
+	if m.QueryVersionDetailFunc != nil {
+		return m.QueryVersionDetailFunc(ctx, biz, opts...)
+	}
+	return new(alipay.VersionDetail), nil
+}
+
+// QueryVersionBuild implements alipay.MiniAPI.
+func (m *Mini) QueryVersionBuild(ctx context.Context, biz *alipay.QueryVersionBuildBiz, opts ...alipay.ValueOptions) (*alipay.QueryVersionBuildResp, error) {
+	m.record("QueryVersionBuild", biz, opts)
+	if m.QueryVersionBuildFunc != nil {
+		return m.QueryVersionBuildFunc(ctx, biz, opts...)
+	}
+	return new(alipay.QueryVersionBuildResp), nil
+}
@@ -0,0 +1,527 @@
+package alipaytest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// 模拟器对非法操作返回的错误
+//
+// 缺少参数时返回支付宝公共错误码isv.invalid-parameter；业务错误统一为40004 Business Failed，
+// 支付宝没有公开这些场景的子错误码，这里的子错误码由模拟器定义，测试中应与这些变量比较，不要依赖具体取值。
+var (
+	ErrVersionNotExist      = BusinessError("VERSION_NOT_EXIST", "小程序版本不存在")
+	ErrVersionExisted       = BusinessError("VERSION_EXISTED", "小程序版本已存在")
+	ErrVersionStatusIllegal = BusinessError("VERSION_STATUS_ILLEGAL", "当前版本状态不允许该操作")
+	ErrVersionBuilding      = BusinessError("VERSION_BUILDING", "小程序版本构建未完成")
+	ErrAuditingExisted      = BusinessError("AUDITING_VERSION_EXISTED", "已有版本在审核中")
+	ErrNoRollbackVersion    = BusinessError("ROLLBACK_VERSION_NOT_EXIST", "没有可回滚的版本")
+	ErrInvalidGrayStrategy  = BusinessError("GRAY_STRATEGY_ILLEGAL", "灰度策略不合法")
+	ErrMissingAppVersion    = &Error{Code: "40002", Msg: "Invalid Arguments", SubCode: "isv.invalid-parameter", SubMsg: "缺少小程序版本号"}
+)
+
+// MiniSimulator 内存中的小程序版本生命周期模拟器
+//
+// 每个商户应用（以app_auth_token区分，未传时为开发者自己的应用）维护独立的版本状态机，
+// 对不合法的状态流转返回与支付宝一致的错误响应。构建、体验版打包和审核会在若干次查询后
+// 自动推进，也可以通过FailBuild、RejectAudit等方法强制指定结果。
+type MiniSimulator struct {
+	BuildPolls   int // 构建完成前QueryVersionBuild的轮询次数，默认1
+	PackagePolls int // 体验版打包完成前QueryExperience的轮询次数，默认1
+	AuditPolls   int // 审核自动通过前QueryVersionDetail的轮询次数，小于0表示不自动通过，默认1
+
+	mu          sync.Mutex
+	apps        map[string]*simApp
+	failBuilds  map[string]bool
+	auditReject map[string]string
+}
+
+type simApp struct {
+	versions map[string]*simVersion
+	released []string // 上架过的版本，最后一个为当前线上版本
+}
+
+type simVersion struct {
+	appVersion   string
+	bundleID     string
+	templateID   string
+	status       alipay.VersionStatus
+	buildStatus  alipay.CreateStatus
+	buildPolls   int
+	expStatus    alipay.ExperiencePackageStatus
+	expPolls     int
+	auditPolls   int
+	grayStrategy alipay.GrayStrategy
+	versionDesc  string
+	rejectReason string
+
+	gmtCreate     time.Time
+	gmtApplyAudit time.Time
+	gmtAuditEnd   time.Time
+	gmtOnline     time.Time
+	gmtOffline    time.Time
+}
+
+// NewMiniSimulator 创建模拟器并在s上注册小程序版本相关接口
+func NewMiniSimulator(s *Server) *MiniSimulator {
+	m := &MiniSimulator{
+		BuildPolls:   1,
+		PackagePolls: 1,
+		AuditPolls:   1,
+		apps:         make(map[string]*simApp),
+		failBuilds:   make(map[string]bool),
+		auditReject:  make(map[string]string),
+	}
+	handlers := map[string]func(r *Request) (interface{}, error){
+		"alipay.open.mini.version.upload":         m.upload,
+		"alipay.open.mini.version.build.query":    m.queryBuild,
+		"alipay.open.mini.version.list.query":     m.queryList,
+		"alipay.open.mini.version.detail.query":   m.queryDetail,
+		"alipay.open.mini.version.delete":         m.delete,
+		"alipay.open.mini.experience.create":      m.createExperience,
+		"alipay.open.mini.experience.query":       m.queryExperience,
+		"alipay.open.mini.experience.cancel":      m.cancelExperience,
+		"alipay.open.mini.version.audit.apply":    m.applyAudit,
+		"alipay.open.mini.version.audit.cancel":   m.cancelAudit,
+		"alipay.open.mini.version.audited.cancel": m.cancelAudited,
+		"alipay.open.mini.version.online":         m.online,
+		"alipay.open.mini.version.gray.online":    m.onlineGray,
+		"alipay.open.mini.version.gray.cancel":    m.cancelGray,
+		"alipay.open.mini.version.rollback":       m.rollback,
+		"alipay.open.mini.version.offline":        m.offline,
+	}
+	for method, h := range handlers {
+		s.HandleFunc(method, m.locked(h))
+	}
+	return m
+}
+
+// FailBuild 使版本的构建失败，可以在上传版本之前调用
+func (m *MiniSimulator) FailBuild(appVersion string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failBuilds[appVersion] = true
+}
+
+// RejectAudit 驳回版本的审核，版本正在审核中时立即生效，否则在下次提交审核后生效
+func (m *MiniSimulator) RejectAudit(appVersion, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auditReject[appVersion] = reason
+	for _, app := range m.apps {
+		if v, ok := app.versions[appVersion]; ok && v.status == alipay.VersionStatusAuditing {
+			m.finishAudit(v)
+		}
+	}
+}
+
+// ApproveAudit 立即通过正在审核中的版本
+func (m *MiniSimulator) ApproveAudit(appVersion string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, app := range m.apps {
+		if v, ok := app.versions[appVersion]; ok && v.status == alipay.VersionStatusAuditing {
+			m.finishAudit(v)
+		}
+	}
+}
+
+// Status 返回商户应用中版本的当前状态，版本不存在时返回空字符串
+func (m *MiniSimulator) Status(appAuthToken, appVersion string) alipay.VersionStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	app, ok := m.apps[appAuthToken]
+	if !ok {
+		return ""
+	}
+	if v, ok := app.versions[appVersion]; ok {
+		return v.status
+	}
+	return ""
+}
+
+func (m *MiniSimulator) locked(h func(r *Request) (interface{}, error)) func(r *Request) (interface{}, error) {
+	return func(r *Request) (interface{}, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return h(r)
+	}
+}
+
+// params 读取业务参数，兼容biz_content和multipart表单两种形式
+func params(r *Request) (map[string]string, error) {
+	p := make(map[string]string)
+	if r.BizContent != "" {
+		var raw map[string]interface{}
+		if err := r.Decode(&raw); err != nil {
+			return nil, err
+		}
+		for k, v := range raw {
+			p[k] = fmt.Sprint(v)
+		}
+		return p, nil
+	}
+	for k := range r.Values {
+		p[k] = r.Values.Get(k)
+	}
+	return p, nil
+}
+
+func (m *MiniSimulator) app(r *Request) *simApp {
+	token := r.AppAuthToken()
+	app, ok := m.apps[token]
+	if !ok {
+		app = &simApp{versions: make(map[string]*simVersion)}
+		m.apps[token] = app
+	}
+	return app
+}
+
+// version 查找请求中app_version对应的版本
+func (m *MiniSimulator) version(r *Request) (*simApp, *simVersion, map[string]string, error) {
+	p, err := params(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if p["app_version"] == "" {
+		return nil, nil, nil, ErrMissingAppVersion
+	}
+	app := m.app(r)
+	v, ok := app.versions[p["app_version"]]
+	if !ok {
+		return nil, nil, nil, ErrVersionNotExist
+	}
+	return app, v, p, nil
+}
+
+func (m *MiniSimulator) upload(r *Request) (interface{}, error) {
+	p, err := params(r)
+	if err != nil {
+		return nil, err
+	}
+	if p["app_version"] == "" {
+		return nil, ErrMissingAppVersion
+	}
+	app := m.app(r)
+	if _, ok := app.versions[p["app_version"]]; ok {
+		return nil, ErrVersionExisted
+	}
+	app.versions[p["app_version"]] = &simVersion{
+		appVersion:  p["app_version"],
+		bundleID:    p["bundle_id"],
+		templateID:  p["template_id"],
+		status:      alipay.VersionStatusInit,
+		buildStatus: alipay.CreateStatusQueued,
+		gmtCreate:   time.Now(),
+	}
+	return nil, nil
+}
+
+func (m *MiniSimulator) queryBuild(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if !v.buildStatus.IsTerminal() {
+		v.buildPolls++
+		switch {
+		case v.buildPolls > m.BuildPolls && m.failBuilds[v.appVersion]:
+			v.buildStatus = alipay.CreateStatusBuildFailed
+		case v.buildPolls > m.BuildPolls:
+			v.buildStatus = alipay.CreateStatusCreated
+		default:
+			v.buildStatus = alipay.CreateStatusBuilding
+		}
+	}
+	return map[string]interface{}{
+		"need_rotation": fmt.Sprint(!v.buildStatus.IsTerminal()),
+		"create_status": v.buildStatus,
+	}, nil
+}
+
+func (m *MiniSimulator) queryList(r *Request) (interface{}, error) {
+	app := m.app(r)
+	versions := make([]string, 0, len(app.versions))
+	for version := range app.versions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return map[string][]string{"app_versions": versions}, nil
+}
+
+func (m *MiniSimulator) queryDetail(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status == alipay.VersionStatusAuditing && m.AuditPolls >= 0 {
+		v.auditPolls++
+		if v.auditPolls > m.AuditPolls {
+			m.finishAudit(v)
+		}
+	}
+	return map[string]interface{}{
+		"app_version":     v.appVersion,
+		"version_desc":    v.versionDesc,
+		"gray_strategy":   v.grayStrategy,
+		"status":          v.status,
+		"reject_reason":   v.rejectReason,
+		"gmt_create":      formatTime(v.gmtCreate),
+		"gmt_apply_audit": formatTime(v.gmtApplyAudit),
+		"gmt_online":      formatTime(v.gmtOnline),
+		"gmt_offline":     formatTime(v.gmtOffline),
+		"gmt_audit_end":   formatTime(v.gmtAuditEnd),
+	}, nil
+}
+
+func (m *MiniSimulator) delete(r *Request) (interface{}, error) {
+	app, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status != alipay.VersionStatusInit && v.status != alipay.VersionStatusAuditReject {
+		return nil, ErrVersionStatusIllegal
+	}
+	delete(app.versions, v.appVersion)
+	return nil, nil
+}
+
+func (m *MiniSimulator) createExperience(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if !builtOK(v) {
+		return nil, ErrVersionBuilding
+	}
+	v.expStatus = alipay.ExpVersionPackaging
+	v.expPolls = 0
+	return nil, nil
+}
+
+func (m *MiniSimulator) queryExperience(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	switch v.expStatus {
+	case "":
+		return map[string]interface{}{"status": alipay.NotExpVersion}, nil
+	case alipay.ExpVersionPackaging:
+		v.expPolls++
+		if v.expPolls > m.PackagePolls {
+			v.expStatus = alipay.ExpVersionPackaged
+		}
+	}
+	resp := map[string]interface{}{"status": v.expStatus}
+	if v.expStatus == alipay.ExpVersionPackaged {
+		resp["exp_qr_code_url"] = "https://qr.alipay.com/exp/" + v.appVersion
+	}
+	return resp, nil
+}
+
+func (m *MiniSimulator) cancelExperience(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.expStatus == "" {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.expStatus = ""
+	return nil, nil
+}
+
+func (m *MiniSimulator) applyAudit(r *Request) (interface{}, error) {
+	app, v, p, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if !builtOK(v) {
+		return nil, ErrVersionBuilding
+	}
+	if v.status != alipay.VersionStatusInit && v.status != alipay.VersionStatusAuditReject {
+		return nil, ErrVersionStatusIllegal
+	}
+	for _, other := range app.versions {
+		if other.status == alipay.VersionStatusAuditing {
+			return nil, ErrAuditingExisted
+		}
+	}
+	v.status = alipay.VersionStatusAuditing
+	v.versionDesc = p["version_desc"]
+	v.rejectReason = ""
+	v.auditPolls = 0
+	v.gmtApplyAudit = time.Now()
+	v.gmtAuditEnd = time.Time{}
+	if _, ok := m.auditReject[v.appVersion]; ok {
+		m.finishAudit(v)
+	}
+	return nil, nil
+}
+
+// finishAudit 结束审核，按RejectAudit的设置决定通过或驳回
+func (m *MiniSimulator) finishAudit(v *simVersion) {
+	v.gmtAuditEnd = time.Now()
+	if reason, ok := m.auditReject[v.appVersion]; ok {
+		delete(m.auditReject, v.appVersion)
+		v.status = alipay.VersionStatusAuditReject
+		v.rejectReason = reason
+		return
+	}
+	v.status = alipay.VersionStatusWaitRelease
+}
+
+func (m *MiniSimulator) cancelAudit(r *Request) (interface{}, error) {
+	p, err := params(r)
+	if err != nil {
+		return nil, err
+	}
+	app := m.app(r)
+	var v *simVersion
+	if p["app_version"] != "" {
+		v = app.versions[p["app_version"]]
+		if v == nil {
+			return nil, ErrVersionNotExist
+		}
+	} else {
+		for _, other := range app.versions {
+			if other.status == alipay.VersionStatusAuditing {
+				v = other
+			}
+		}
+		if v == nil {
+			return nil, ErrVersionNotExist
+		}
+	}
+	if v.status != alipay.VersionStatusAuditing {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.status = alipay.VersionStatusInit
+	v.gmtApplyAudit = time.Time{}
+	return nil, nil
+}
+
+func (m *MiniSimulator) cancelAudited(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status != alipay.VersionStatusWaitRelease && v.status != alipay.VersionStatusAuditReject {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.status = alipay.VersionStatusInit
+	return nil, nil
+}
+
+func (m *MiniSimulator) online(r *Request) (interface{}, error) {
+	app, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status != alipay.VersionStatusWaitRelease && v.status != alipay.VersionStatusGray {
+		return nil, ErrVersionStatusIllegal
+	}
+	if current := app.current(); current != nil {
+		current.status = alipay.VersionStatusOffline
+		current.gmtOffline = time.Now()
+	}
+	v.status = alipay.VersionStatusRelease
+	v.grayStrategy = ""
+	v.gmtOnline = time.Now()
+	app.released = append(app.released, v.appVersion)
+	return nil, nil
+}
+
+func (m *MiniSimulator) onlineGray(r *Request) (interface{}, error) {
+	_, v, p, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := alipay.ParseGrayStrategy(p["gray_strategy"])
+	if err != nil {
+		return nil, ErrInvalidGrayStrategy
+	}
+	if v.status != alipay.VersionStatusWaitRelease && v.status != alipay.VersionStatusGray {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.status = alipay.VersionStatusGray
+	v.grayStrategy = strategy
+	return nil, nil
+}
+
+func (m *MiniSimulator) cancelGray(r *Request) (interface{}, error) {
+	_, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status != alipay.VersionStatusGray {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.status = alipay.VersionStatusWaitRelease
+	v.grayStrategy = ""
+	return nil, nil
+}
+
+func (m *MiniSimulator) rollback(r *Request) (interface{}, error) {
+	app, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	// 只能回滚当前线上版本
+	if v.status != alipay.VersionStatusRelease || app.current() != v {
+		return nil, ErrVersionStatusIllegal
+	}
+	if len(app.released) < 2 || app.versions[app.released[len(app.released)-2]] == nil {
+		return nil, ErrNoRollbackVersion
+	}
+	app.released = app.released[:len(app.released)-1]
+	previous := app.versions[app.released[len(app.released)-1]]
+	v.status = alipay.VersionStatusWaitRelease
+	v.gmtOnline = time.Time{}
+	previous.status = alipay.VersionStatusRelease
+	previous.gmtOnline = time.Now()
+	previous.gmtOffline = time.Time{}
+	return nil, nil
+}
+
+func (m *MiniSimulator) offline(r *Request) (interface{}, error) {
+	app, v, _, err := m.version(r)
+	if err != nil {
+		return nil, err
+	}
+	if v.status != alipay.VersionStatusRelease {
+		return nil, ErrVersionStatusIllegal
+	}
+	v.status = alipay.VersionStatusOffline
+	v.gmtOffline = time.Now()
+	app.released = app.released[:0]
+	return nil, nil
+}
+
+// current 当前线上版本
+func (a *simApp) current() *simVersion {
+	if len(a.released) == 0 {
+		return nil
+	}
+	v := a.versions[a.released[len(a.released)-1]]
+	if v == nil || v.status != alipay.VersionStatusRelease {
+		return nil
+	}
+	return v
+}
+
+func builtOK(v *simVersion) bool {
+	return v.buildStatus == alipay.CreateStatusBuildSuccess || v.buildStatus == alipay.CreateStatusCreated
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(alipay.Location).Format("2006-01-02 15:04:05")
+}
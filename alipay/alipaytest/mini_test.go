@@ -0,0 +1,168 @@
+package alipaytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// waitBuild 轮询构建状态直到构建结束
+func waitBuild(t *testing.T, client *alipay.Client, version string, opts ...alipay.ValueOptions) alipay.CreateStatus {
+	t.Helper()
+	client.PollBackoff = &alipay.Backoff{Initial: time.Millisecond}
+	resp, err := client.Mini.WaitForBuild(context.Background(), version, opts...)
+	var buildErr *alipay.BuildError
+	if errors.As(err, &buildErr) {
+		return buildErr.Status
+	}
+	if err != nil {
+		t.Fatalf("Mini.WaitForBuild returned unexcepted error: %v", err)
+	}
+	return resp.CreateStatus
+}
+
+func wantSubCode(t *testing.T, err error, want *Error) {
+	t.Helper()
+	var errResp *alipay.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.SubCode != want.SubCode {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+}
+
+func TestMiniSimulator_lifecycle(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	sim := NewMiniSimulator(s)
+	client := s.Client()
+	ctx := context.Background()
+
+	if err := client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1"}); err != nil {
+		t.Fatalf("Mini.UploadVersion returned unexcepted error: %v", err)
+	}
+	wantSubCode(t, client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1"}), ErrVersionExisted)
+	wantSubCode(t, client.Mini.CreateExperience(ctx, &alipay.CreateExperienceBiz{AppVersion: "0.0.1"}), ErrVersionBuilding)
+
+	if got := waitBuild(t, client, "0.0.1"); got != alipay.CreateStatusCreated {
+		t.Fatalf("build status got %v, want 6", got)
+	}
+
+	if err := client.Mini.CreateExperience(ctx, &alipay.CreateExperienceBiz{AppVersion: "0.0.1"}); err != nil {
+		t.Fatalf("Mini.CreateExperience returned unexcepted error: %v", err)
+	}
+	exp, _ := client.Mini.QueryExperience(ctx, &alipay.QueryExperienceBiz{AppVersion: "0.0.1"})
+	if exp.Status != "expVersionPackaging" {
+		t.Errorf("Mini.QueryExperience status got %v, want expVersionPackaging", exp.Status)
+	}
+	exp, _ = client.Mini.QueryExperience(ctx, &alipay.QueryExperienceBiz{AppVersion: "0.0.1"})
+	if exp.Status != "expVersionPackaged" || exp.ExpQrCodeURL == "" {
+		t.Errorf("Mini.QueryExperience got %+v, want packaged with qr code", exp)
+	}
+
+	wantSubCode(t, client.Mini.OnlineVersion(ctx, &alipay.OnlineVersionBiz{AppVersion: "0.0.1"}), ErrVersionStatusIllegal)
+
+	err := client.Mini.ApplyVersionAudit(ctx, &alipay.ApplyVersionAuditBiz{
+		AppVersion:      "0.0.1",
+		VersionDesc:     "小程序版本描述小程序版本描述",
+		RegionType:      "CHINA",
+		FirstScreenShot: &alipay.File{Name: "1.png", Content: bytes.NewReader([]byte("1"))},
+	})
+	if err != nil {
+		t.Fatalf("Mini.ApplyVersionAudit returned unexcepted error: %v", err)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusAuditing {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusAuditing)
+	}
+	detail, _ := client.Mini.QueryVersionDetail(ctx, &alipay.QueryVersionDetailBiz{AppVersion: "0.0.1"})
+	if detail.Status != alipay.VersionStatusAuditing || detail.GmtApplyAudit.IsZero() {
+		t.Errorf("Mini.QueryVersionDetail got %+v, want auditing", detail)
+	}
+	detail, _ = client.Mini.QueryVersionDetail(ctx, &alipay.QueryVersionDetailBiz{AppVersion: "0.0.1"})
+	if detail.Status != alipay.VersionStatusWaitRelease {
+		t.Errorf("Mini.QueryVersionDetail status got %v, want %v", detail.Status, alipay.VersionStatusWaitRelease)
+	}
+
+	client.SkipValidation = true
+	wantSubCode(t, client.Mini.OnlineGrayVersion(ctx, &alipay.OnlineGrayVersionBiz{AppVersion: "0.0.1", GrayStrategy: "p20"}), ErrInvalidGrayStrategy)
+	client.SkipValidation = false
+	if err := client.Mini.OnlineGrayVersion(ctx, &alipay.OnlineGrayVersionBiz{AppVersion: "0.0.1", GrayStrategy: "p10"}); err != nil {
+		t.Fatalf("Mini.OnlineGrayVersion returned unexcepted error: %v", err)
+	}
+	if err := client.Mini.OnlineVersion(ctx, &alipay.OnlineVersionBiz{AppVersion: "0.0.1"}); err != nil {
+		t.Fatalf("Mini.OnlineVersion returned unexcepted error: %v", err)
+	}
+	wantSubCode(t, client.Mini.RollbackVersion(ctx, &alipay.RollbackVersionBiz{AppVersion: "0.0.1"}), ErrNoRollbackVersion)
+
+	list, _ := client.Mini.QueryVersionList(ctx)
+	if len(list.AppVersions) != 1 || list.AppVersions[0] != "0.0.1" {
+		t.Errorf("Mini.QueryVersionList got %v, want [0.0.1]", list.AppVersions)
+	}
+}
+
+func TestMiniSimulator_rollback(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	sim := NewMiniSimulator(s)
+	sim.AuditPolls = -1
+	client := s.Client()
+	ctx := context.Background()
+
+	for _, version := range []string{"0.0.1", "0.0.2"} {
+		if err := client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: version, TemplateID: "1"}); err != nil {
+			t.Fatalf("Mini.UploadVersion returned unexcepted error: %v", err)
+		}
+		waitBuild(t, client, version)
+		if err := client.Mini.ApplyVersionAudit(ctx, &alipay.ApplyVersionAuditBiz{AppVersion: version, VersionDesc: "版本描述"}); err != nil {
+			t.Fatalf("Mini.ApplyVersionAudit returned unexcepted error: %v", err)
+		}
+		sim.ApproveAudit(version)
+		if err := client.Mini.OnlineVersion(ctx, &alipay.OnlineVersionBiz{AppVersion: version}); err != nil {
+			t.Fatalf("Mini.OnlineVersion returned unexcepted error: %v", err)
+		}
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusOffline {
+		t.Errorf("status of 0.0.1 got %v, want %v", got, alipay.VersionStatusOffline)
+	}
+	// 只能回滚当前线上版本
+	wantSubCode(t, client.Mini.RollbackVersion(ctx, &alipay.RollbackVersionBiz{AppVersion: "0.0.1"}), ErrVersionStatusIllegal)
+	if err := client.Mini.RollbackVersion(ctx, &alipay.RollbackVersionBiz{AppVersion: "0.0.2"}); err != nil {
+		t.Fatalf("Mini.RollbackVersion returned unexcepted error: %v", err)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusRelease {
+		t.Errorf("status of 0.0.1 got %v, want %v", got, alipay.VersionStatusRelease)
+	}
+	if err := client.Mini.OfflineVersion(ctx, &alipay.OfflineVersionBiz{AppVersion: "0.0.1"}); err != nil {
+		t.Fatalf("Mini.OfflineVersion returned unexcepted error: %v", err)
+	}
+}
+
+func TestMiniSimulator_hooks(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	sim := NewMiniSimulator(s)
+	client := s.Client()
+	ctx := context.Background()
+
+	sim.FailBuild("0.0.1")
+	_ = client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1"})
+	if got := waitBuild(t, client, "0.0.1"); got != alipay.CreateStatusBuildFailed {
+		t.Errorf("build status got %v, want 3", got)
+	}
+	wantSubCode(t, client.Mini.ApplyVersionAudit(ctx, &alipay.ApplyVersionAuditBiz{AppVersion: "0.0.1", VersionDesc: "版本描述"}), ErrVersionBuilding)
+
+	_ = client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: "0.0.2", TemplateID: "1"}, alipay.AppAuthToken("merchant"))
+	waitBuild(t, client, "0.0.2", alipay.AppAuthToken("merchant"))
+	wantSubCode(t, client.Mini.ApplyVersionAudit(ctx, &alipay.ApplyVersionAuditBiz{AppVersion: "0.0.2", VersionDesc: "版本描述"}), ErrVersionNotExist)
+
+	sim.RejectAudit("0.0.2", "信息不完整")
+	if err := client.Mini.ApplyVersionAudit(ctx, &alipay.ApplyVersionAuditBiz{AppVersion: "0.0.2", VersionDesc: "版本描述"}, alipay.AppAuthToken("merchant")); err != nil {
+		t.Fatalf("Mini.ApplyVersionAudit returned unexcepted error: %v", err)
+	}
+	detail, _ := client.Mini.QueryVersionDetail(ctx, &alipay.QueryVersionDetailBiz{AppVersion: "0.0.2"}, alipay.AppAuthToken("merchant"))
+	if detail.Status != alipay.VersionStatusAuditReject || detail.RejectReason != "信息不完整" {
+		t.Errorf("Mini.QueryVersionDetail got %+v, want rejected", detail)
+	}
+}
@@ -0,0 +1,242 @@
+package alipaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mode 录制回放模式
+type Mode int
+
+// 录制回放模式
+const (
+	ModeRecord Mode = iota // 请求真实网关并记录交互
+	ModeReplay             // 从fixture文件回放交互，不发出网络请求
+)
+
+// Interaction 一次录制的请求和响应
+type Interaction struct {
+	Method     string            `json:"method"`
+	Fields     map[string]string `json:"fields"`          // 请求表单字段，不含sign和timestamp
+	Files      map[string]string `json:"files,omitempty"` // multipart请求中的文件字段名和文件名
+	StatusCode int               `json:"status_code"`
+	Response   string            `json:"response"`
+}
+
+// NoInteractionError 回放模式下找不到匹配的录制交互
+type NoInteractionError struct {
+	Method     string
+	BizContent string
+}
+
+func (e *NoInteractionError) Error() string {
+	return fmt.Sprintf("alipaytest: no recorded interaction for %v %v", e.Method, e.BizContent)
+}
+
+// Recorder 录制和回放支付宝请求的http.RoundTripper
+//
+// 录制模式下请求通过Transport发送到真实网关，并记录去掉sign和timestamp后的请求字段
+// 与响应内容，调用Save写入fixture文件；回放模式下按接口方法和biz_content
+// （multipart请求按业务字段）依次匹配录制的交互。
+type Recorder struct {
+	Mode      Mode
+	Path      string            // fixture文件路径
+	Transport http.RoundTripper // 录制模式下使用的真实Transport，为nil时使用http.DefaultTransport
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewRecorder 创建Recorder，回放模式下会读取path中的fixture
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{Mode: mode, Path: path, Transport: transport}
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("alipaytest: invalid fixture %v: %w", path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Interactions 返回已录制或加载的交互
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+// Save 将录制的交互写入fixture文件
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, data, os.FileMode(0644))
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	fields, files, err := parseFields(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+	if r.Mode == ModeReplay {
+		return r.replay(req, fields)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Method:     fields["method"],
+		Fields:     fields,
+		Files:      files,
+		StatusCode: resp.StatusCode,
+		Response:   string(data),
+	})
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, fields map[string]string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || !matches(in.Fields, fields) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+			StatusCode:    in.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json;charset=utf-8"}},
+			Body:          ioutil.NopCloser(strings.NewReader(in.Response)),
+			ContentLength: int64(len(in.Response)),
+			Request:       req,
+		}, nil
+	}
+	return nil, &NoInteractionError{Method: fields["method"], BizContent: fields["biz_content"]}
+}
+
+// systemFields 公共请求参数，回放匹配multipart请求时忽略
+var systemFields = map[string]bool{
+	"app_id":    true,
+	"format":    true,
+	"charset":   true,
+	"sign_type": true,
+	"sign":      true,
+	"timestamp": true,
+	"version":   true,
+}
+
+// matches 接口方法、app_auth_token和biz_content相同，没有biz_content时比较业务字段
+func matches(recorded, fields map[string]string) bool {
+	if recorded["method"] != fields["method"] ||
+		recorded["app_auth_token"] != fields["app_auth_token"] ||
+		recorded["biz_content"] != fields["biz_content"] {
+		return false
+	}
+	if fields["biz_content"] != "" {
+		return true
+	}
+	for k, v := range fields {
+		if !systemFields[k] && recorded[k] != v {
+			return false
+		}
+	}
+	for k := range recorded {
+		if _, ok := fields[k]; !ok && !systemFields[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFields 解析请求表单字段，去掉sign和timestamp
+func parseFields(contentType string, body []byte) (map[string]string, map[string]string, error) {
+	fields := make(map[string]string)
+	files := make(map[string]string)
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if part.FileName() != "" {
+				files[part.FormName()] = part.FileName()
+				continue
+			}
+			value, err := ioutil.ReadAll(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			fields[part.FormName()] = string(value)
+		}
+	} else {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		for k := range values {
+			fields[k] = values.Get(k)
+		}
+	}
+	delete(fields, "sign")
+	delete(fields, "timestamp")
+	if len(files) == 0 {
+		files = nil
+	}
+	return fields, files, nil
+}
@@ -0,0 +1,80 @@
+package alipaytest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alipaytest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	s := NewServer()
+	s.HandleFunc("alipay.open.mini.experience.query", func(r *Request) (interface{}, error) {
+		var biz alipay.QueryExperienceBiz
+		_ = r.Decode(&biz)
+		return &alipay.ExperienceStatus{Status: "expVersionPackaged", ExpQrCodeURL: "https://example.com/" + biz.AppVersion}, nil
+	})
+	newClient := func(rt http.RoundTripper) *alipay.Client {
+		c := alipay.NewClient(&http.Client{Transport: rt}, s.AppPrivateKey, &s.AlipayPrivateKey.PublicKey, alipay.AppID(s.AppID))
+		c.BaseURL, _ = url.Parse(s.URL)
+		return c
+	}
+
+	rec, _ := NewRecorder(path, ModeRecord, nil)
+	client := newClient(rec)
+	want := make(map[string]*alipay.ExperienceStatus)
+	for _, version := range []string{"0.0.1", "0.0.2"} {
+		got, err := client.Mini.QueryExperience(context.Background(), &alipay.QueryExperienceBiz{AppVersion: version})
+		if err != nil {
+			t.Fatalf("Mini.QueryExperience returned unexcepted error: %v", err)
+		}
+		want[version] = got
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Recorder.Save returned unexcepted error: %v", err)
+	}
+	for _, in := range rec.Interactions() {
+		if _, ok := in.Fields["sign"]; ok {
+			t.Errorf("recorded fields should not contain sign")
+		}
+		if _, ok := in.Fields["timestamp"]; ok {
+			t.Errorf("recorded fields should not contain timestamp")
+		}
+	}
+	s.Close()
+
+	rec, err = NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned unexcepted error: %v", err)
+	}
+	client = newClient(rec)
+	for _, version := range []string{"0.0.2", "0.0.1"} {
+		got, err := client.Mini.QueryExperience(context.Background(), &alipay.QueryExperienceBiz{AppVersion: version})
+		if err != nil {
+			t.Fatalf("Mini.QueryExperience replay returned unexcepted error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want[version]) {
+			t.Errorf("Mini.QueryExperience replay got %+v, want %+v", got, want[version])
+		}
+	}
+
+	_, err = client.Mini.QueryExperience(context.Background(), &alipay.QueryExperienceBiz{AppVersion: "0.0.1"})
+	var noInteraction *NoInteractionError
+	if !errors.As(err, &noInteraction) {
+		t.Errorf("Mini.QueryExperience replay got %v, want *NoInteractionError", err)
+	}
+}
@@ -0,0 +1,313 @@
+// Package alipaytest 提供用于测试的支付宝网关模拟服务
+//
+// Server会生成自己的密钥对，对收到的请求验签，按method表单字段路由到注册的处理函数，
+// 并像支付宝一样对响应签名，Client返回的*alipay.Client已配置好对应的密钥和地址。
+package alipaytest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// DefaultAppID 模拟网关默认的应用ID
+const DefaultAppID = "2021000000000000"
+
+// Error 支付宝错误响应，处理函数返回*Error时按支付宝的错误格式输出
+type Error struct {
+	Code    string
+	Msg     string
+	SubCode string
+	SubMsg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v %v, %v %v", e.Code, e.Msg, e.SubCode, e.SubMsg)
+}
+
+// BusinessError 业务处理失败
+func BusinessError(subCode, subMsg string) *Error {
+	return &Error{Code: "40004", Msg: "Business Failed", SubCode: subCode, SubMsg: subMsg}
+}
+
+// 网关层面的错误
+var (
+	ErrSystem           = &Error{Code: "20000", Msg: "Service Currently Unavailable", SubCode: "isp.unknow-error", SubMsg: "系统繁忙"}
+	ErrMissingMethod    = &Error{Code: "40001", Msg: "Missing Required Arguments", SubCode: "isv.missing-method", SubMsg: "缺少方法名参数"}
+	ErrInvalidMethod    = &Error{Code: "40002", Msg: "Invalid Arguments", SubCode: "isv.invalid-method", SubMsg: "不存在的方法名"}
+	ErrInvalidAppID     = &Error{Code: "40002", Msg: "Invalid Arguments", SubCode: "isv.invalid-app-id", SubMsg: "无效的AppID参数"}
+	ErrInvalidSignature = &Error{Code: "40002", Msg: "Invalid Arguments", SubCode: "isv.invalid-signature", SubMsg: "验签出错"}
+)
+
+// File 请求中上传的文件
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Request 模拟网关收到的请求
+type Request struct {
+	Method     string           // 接口方法名
+	Values     url.Values       // 请求表单字段，不含文件
+	Files      map[string]*File // multipart请求中上传的文件
+	BizContent string           // biz_content参数
+
+	HTTPRequest *http.Request
+}
+
+// AppAuthToken 第三方应用授权令牌
+func (r *Request) AppAuthToken() string {
+	return r.Values.Get("app_auth_token")
+}
+
+// Decode 将biz_content反序列化到v
+func (r *Request) Decode(v interface{}) error {
+	if r.BizContent == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(r.BizContent), v)
+}
+
+// Handler 处理某个接口方法的请求
+//
+// 返回值会被序列化为JSON对象并补充code和msg字段，返回*Error时输出对应的错误响应，
+// 返回其他错误时输出系统错误。
+type Handler interface {
+	ServeAlipay(r *Request) (interface{}, error)
+}
+
+// HandlerFunc 函数形式的Handler
+type HandlerFunc func(r *Request) (interface{}, error)
+
+// ServeAlipay calls f(r).
+func (f HandlerFunc) ServeAlipay(r *Request) (interface{}, error) {
+	return f(r)
+}
+
+// Server 模拟支付宝网关
+type Server struct {
+	URL   string
+	AppID string
+
+	AppPrivateKey    *rsa.PrivateKey // 开发者应用私钥，Client使用它对请求签名
+	AlipayPrivateKey *rsa.PrivateKey // 支付宝私钥，Server使用它对响应签名
+
+	server *httptest.Server
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewServer 启动模拟网关，使用完毕后需要调用Close
+func NewServer() *Server {
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("alipaytest: failed to generate app key: %v", err))
+	}
+	alipayKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("alipaytest: failed to generate alipay key: %v", err))
+	}
+	s := &Server{
+		AppID:            DefaultAppID,
+		AppPrivateKey:    appKey,
+		AlipayPrivateKey: alipayKey,
+		handlers:         make(map[string]Handler),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close 关闭模拟网关
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client 返回配置好密钥、应用ID和网关地址的客户端
+func (s *Server) Client(setters ...alipay.Option) *alipay.Client {
+	setters = append([]alipay.Option{alipay.AppID(s.AppID)}, setters...)
+	c := alipay.NewClient(s.server.Client(), s.AppPrivateKey, &s.AlipayPrivateKey.PublicKey, setters...)
+	c.BaseURL, _ = url.Parse(s.URL)
+	return c
+}
+
+// Handle 注册接口方法的处理函数
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+// HandleFunc 注册接口方法的处理函数
+func (s *Server) HandleFunc(method string, f func(r *Request) (interface{}, error)) {
+	s.Handle(method, HandlerFunc(f))
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		s.writeResponse(w, "", "", nil, &Error{Code: "40002", Msg: "Invalid Arguments", SubCode: "isv.invalid-parameter", SubMsg: err.Error()})
+		return
+	}
+	if req.Method == "" {
+		s.writeResponse(w, "", req.Values.Get("sign_type"), nil, ErrMissingMethod)
+		return
+	}
+	s.mu.RLock()
+	h, ok := s.handlers[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		s.writeResponse(w, "", req.Values.Get("sign_type"), nil, ErrInvalidMethod)
+		return
+	}
+	if req.Values.Get("app_id") != s.AppID {
+		s.writeResponse(w, req.Method, req.Values.Get("sign_type"), nil, ErrInvalidAppID)
+		return
+	}
+	if err := s.verify(req.Values); err != nil {
+		s.writeResponse(w, req.Method, req.Values.Get("sign_type"), nil, ErrInvalidSignature)
+		return
+	}
+	resp, err := h.ServeAlipay(req)
+	s.writeResponse(w, req.Method, req.Values.Get("sign_type"), resp, err)
+}
+
+func parseRequest(r *http.Request) (*Request, error) {
+	req := &Request{HTTPRequest: r, Files: make(map[string]*File)}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+		req.Values = url.Values(r.MultipartForm.Value)
+		for key, headers := range r.MultipartForm.File {
+			if len(headers) == 0 {
+				continue
+			}
+			f, err := headers[0].Open()
+			if err != nil {
+				return nil, err
+			}
+			content, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Files[key] = &File{Name: headers[0].Filename, Content: content}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		req.Values = r.PostForm
+	}
+	req.Method = req.Values.Get("method")
+	req.BizContent = req.Values.Get("biz_content")
+	return req, nil
+}
+
+func hashFor(signType string) crypto.Hash {
+	if signType == "RSA" {
+		return crypto.SHA1
+	}
+	return crypto.SHA256
+}
+
+// verify 按支付宝规则校验请求签名：除sign外的非空参数按key排序拼接
+func (s *Server) verify(values url.Values) error {
+	sign, err := base64.StdEncoding.DecodeString(values.Get("sign"))
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var buf strings.Builder
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if v == "" {
+				continue
+			}
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(k)
+			buf.WriteByte('=')
+			buf.WriteString(v)
+		}
+	}
+	hash := hashFor(values.Get("sign_type"))
+	h := hash.New()
+	h.Write([]byte(buf.String()))
+	return rsa.VerifyPKCS1v15(&s.AppPrivateKey.PublicKey, hash, h.Sum(nil), sign)
+}
+
+// writeResponse 输出并签名响应，method为空时使用error_response作为响应key
+func (s *Server) writeResponse(w http.ResponseWriter, method, signType string, resp interface{}, err error) {
+	body := make(map[string]json.RawMessage)
+	if err == nil && resp != nil {
+		data, mErr := json.Marshal(resp)
+		if mErr == nil {
+			mErr = json.Unmarshal(data, &body)
+		}
+		if mErr != nil {
+			err = mErr
+		}
+	}
+	e, ok := err.(*Error)
+	if err != nil && !ok {
+		e = &Error{Code: ErrSystem.Code, Msg: ErrSystem.Msg, SubCode: ErrSystem.SubCode, SubMsg: err.Error()}
+	}
+	if e != nil {
+		body = map[string]json.RawMessage{
+			"code": marshalString(e.Code),
+			"msg":  marshalString(e.Msg),
+		}
+		if e.SubCode != "" {
+			body["sub_code"] = marshalString(e.SubCode)
+		}
+		if e.SubMsg != "" {
+			body["sub_msg"] = marshalString(e.SubMsg)
+		}
+	} else {
+		body["code"] = marshalString("10000")
+		body["msg"] = marshalString("Success")
+	}
+	payload, _ := json.Marshal(body)
+
+	key := "error_response"
+	if method != "" {
+		key = strings.Replace(method, ".", "_", -1) + "_response"
+	}
+	hash := hashFor(signType)
+	h := hash.New()
+	h.Write(payload)
+	signature, sErr := rsa.SignPKCS1v15(rand.Reader, s.AlipayPrivateKey, hash, h.Sum(nil))
+	if sErr != nil {
+		http.Error(w, sErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	fmt.Fprintf(w, `{%s:%s,"sign":%s}`, marshalString(key), payload, marshalString(base64.StdEncoding.EncodeToString(signature)))
+}
+
+func marshalString(s string) json.RawMessage {
+	data, _ := json.Marshal(s)
+	return data
+}
@@ -0,0 +1,115 @@
+package alipaytest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.HandleFunc("alipay.open.mini.experience.query", func(r *Request) (interface{}, error) {
+		var biz alipay.QueryExperienceBiz
+		if err := r.Decode(&biz); err != nil {
+			return nil, err
+		}
+		if biz.AppVersion != "0.0.1" {
+			return nil, BusinessError("VERSION_NOT_EXIST", "版本不存在")
+		}
+		return &alipay.ExperienceStatus{Status: "expVersionPackaged", ExpQrCodeURL: "https://example.com/qr"}, nil
+	})
+
+	client := s.Client()
+	got, err := client.Mini.QueryExperience(context.Background(), &alipay.QueryExperienceBiz{AppVersion: "0.0.1"})
+	if err != nil {
+		t.Fatalf("Mini.QueryExperience returned unexcepted error: %v", err)
+	}
+	want := &alipay.ExperienceStatus{Status: "expVersionPackaged", ExpQrCodeURL: "https://example.com/qr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mini.QueryExperience got %+v, want %+v", got, want)
+	}
+
+	_, err = client.Mini.QueryExperience(context.Background(), &alipay.QueryExperienceBiz{AppVersion: "0.0.2"})
+	var errResp *alipay.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.SubCode != "VERSION_NOT_EXIST" {
+		t.Errorf("Mini.QueryExperience got %v, want sub code VERSION_NOT_EXIST", err)
+	}
+}
+
+func TestServer_signType(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.HandleFunc("alipay.open.mini.version.list.query", func(r *Request) (interface{}, error) {
+		return &alipay.QueryVersionListResp{AppVersions: []string{"0.0.1"}}, nil
+	})
+
+	client := s.Client(alipay.SignType("RSA"))
+	if _, err := client.Mini.QueryVersionList(context.Background()); err != nil {
+		t.Errorf("Mini.QueryVersionList returned unexcepted error: %v", err)
+	}
+}
+
+func TestServer_invalidSignature(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.HandleFunc("alipay.open.mini.version.list.query", func(r *Request) (interface{}, error) {
+		t.Error("handler should not be called")
+		return nil, nil
+	})
+
+	client := s.Client()
+	client.PrivateKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	_, err := client.Mini.QueryVersionList(context.Background())
+	var errResp *alipay.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.SubCode != ErrInvalidSignature.SubCode {
+		t.Errorf("Mini.QueryVersionList got %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestServer_invalidMethod(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	_, err := s.Client().Mini.QueryVersionList(context.Background())
+	var errResp *alipay.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.SubCode != ErrInvalidMethod.SubCode {
+		t.Errorf("Mini.QueryVersionList got %v, want %v", err, ErrInvalidMethod)
+	}
+}
+
+func TestServer_multipart(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	var got *Request
+	s.HandleFunc("alipay.open.mini.baseinfo.modify", func(r *Request) (interface{}, error) {
+		got = r
+		return nil, nil
+	})
+
+	err := s.Client().Mini.ModifyBaseInfo(context.Background(), &alipay.ModifyBaseInfoBiz{
+		AppName: "小程序示例",
+		AppLogo: &alipay.File{Name: "logo.png", Content: bytes.NewReader([]byte("logo"))},
+	}, alipay.AppAuthToken("token"))
+	if err != nil {
+		t.Fatalf("Mini.ModifyBaseInfo returned unexcepted error: %v", err)
+	}
+	if got.Values.Get("app_name") != "小程序示例" {
+		t.Errorf("Request app_name got %v, want %v", got.Values.Get("app_name"), "小程序示例")
+	}
+	if got.AppAuthToken() != "token" {
+		t.Errorf("Request.AppAuthToken got %v, want %v", got.AppAuthToken(), "token")
+	}
+	want := &File{Name: "logo.png", Content: []byte("logo")}
+	if !reflect.DeepEqual(got.Files["app_logo"], want) {
+		t.Errorf("Request file app_logo got %+v, want %+v", got.Files["app_logo"], want)
+	}
+}
@@ -0,0 +1,81 @@
+package alipay
+
+import "context"
+
+// AppAPI 应用服务接口，*AppService实现了该接口，业务代码依赖AppAPI便于在测试中替换实现
+type AppAPI interface {
+	// CreateMember 应用添加成员，目前只支持小程序类型的应用使用
+	CreateMember(ctx context.Context, biz *CreateAppMemberBiz, opts ...ValueOptions) error
+	// DeleteMember 应用删除成员，目前只支持小程序类型的应用使用
+	DeleteMember(ctx context.Context, biz *DeleteMemberBiz, opts ...ValueOptions) error
+	// QueryAppMembers 应用查询成员列表，目前只支持小程序类型的应用
+	QueryAppMembers(ctx context.Context, biz *QueryAppMembersBiz, opts ...ValueOptions) (*QueryAppMembersResp, error)
+	// CreateAppQRCode 生成小程序推广二维码
+	CreateAppQRCode(ctx context.Context, biz *CreateAppQRCodeBiz, opts ...ValueOptions) (*CreateAppQRCodeResp, error)
+}
+
+// MiniAPI 小程序服务接口，*MiniService实现了该接口，业务代码依赖MiniAPI便于在测试中替换实现
+//
+// MiniAPI只包含支付宝开放平台的接口，WaitForBuild、WatchVersion等组合多个接口的辅助函数
+// 接受MiniAPI作为参数，任何实现都可以直接使用。
+type MiniAPI interface {
+	// QueryBaseInfo 查询小程序基础信息
+	QueryBaseInfo(ctx context.Context, opts ...ValueOptions) (*BaseInfo, error)
+	// ModifyBaseInfo 小程序修改基础信息
+	ModifyBaseInfo(ctx context.Context, biz *ModifyBaseInfoBiz, opts ...ValueOptions) error
+	// CreateSafeDomain 小程序添加域白名单
+	CreateSafeDomain(ctx context.Context, biz *CreateSafeDomainBiz, opts ...ValueOptions) error
+	// DetectRiskContent 小程序风险内容检测服务
+	DetectRiskContent(ctx context.Context, biz *DetectRiskContentBiz, opts ...ValueOptions) (*DetectRiskContentResp, error)
+	// QueryTinyAppExist 查询是否创建过小程序
+	QueryTinyAppExist(ctx context.Context, biz *QueryTinyAppExistBiz, opts ...ValueOptions) (*QueryTinyAppExistResp, error)
+	// QueryCategory 小程序类目树查询
+	QueryCategory(ctx context.Context, biz *QueryCategoryBiz, opts ...ValueOptions) (*QueryCategoryResp, error)
+	// CertifyIndividualBusiness 个人账户升级为个体工商户
+	CertifyIndividualBusiness(ctx context.Context, biz *CertifyIndividualBusinessBiz, opts ...ValueOptions) (*CertifyIndividualBusinessResp, error)
+	// SyncContent 小程序内容接入
+	SyncContent(ctx context.Context, biz *SyncContentBiz, opts ...ValueOptions) (*SyncContentResp, error)
+	// CreateExperience 小程序生成体验版
+	CreateExperience(ctx context.Context, biz *CreateExperienceBiz, opts ...ValueOptions) error
+	// QueryExperience 小程序体验版状态查询
+	QueryExperience(ctx context.Context, biz *QueryExperienceBiz, opts ...ValueOptions) (*ExperienceStatus, error)
+	// CancelExperience 小程序取消体验版
+	CancelExperience(ctx context.Context, biz *CancelExperienceBiz, opts ...ValueOptions) error
+	// BindQrCode 关联普通二维码
+	BindQrCode(ctx context.Context, biz *BindQrCodeBiz, opts ...ValueOptions) (*BindQrCodeResp, error)
+	// UnbindQrCode 删除已关联普通二维码
+	UnbindQrCode(ctx context.Context, biz *UnbindQrCodeBiz, opts ...ValueOptions) error
+	// QueryTemplateUsage 查询使用模板的小程序列表
+	QueryTemplateUsage(ctx context.Context, biz *QueryTemplateUsageBiz, opts ...ValueOptions) (*QueryTemplateUsageResp, error)
+	// QueryVersionList 查询小程序列表
+	QueryVersionList(ctx context.Context, opts ...ValueOptions) (*QueryVersionListResp, error)
+	// DeleteVersion 小程序删除版本
+	DeleteVersion(ctx context.Context, biz *DeleteVersionBiz, opts ...ValueOptions) error
+	// ApplyVersionAudit 小程序提交审核
+	ApplyVersionAudit(ctx context.Context, biz *ApplyVersionAuditBiz, opts ...ValueOptions) error
+	// CancelVersionAudit 小程序撤销审核
+	CancelVersionAudit(ctx context.Context, biz *CancelVersionAuditBiz, opts ...ValueOptions) error
+	// CancelVersionAudited 小程序退回开发
+	CancelVersionAudited(ctx context.Context, biz *CancelVersionAuditedBiz, opts ...ValueOptions) error
+	// OnlineVersion 小程序上架
+	OnlineVersion(ctx context.Context, biz *OnlineVersionBiz, opts ...ValueOptions) error
+	// OfflineVersion 小程序下架
+	OfflineVersion(ctx context.Context, biz *OfflineVersionBiz, opts ...ValueOptions) error
+	// RollbackVersion 小程序回滚
+	RollbackVersion(ctx context.Context, biz *RollbackVersionBiz, opts ...ValueOptions) error
+	// OnlineGrayVersion 小程序灰度上架
+	OnlineGrayVersion(ctx context.Context, biz *OnlineGrayVersionBiz, opts ...ValueOptions) error
+	// CancelGrayVersion 小程序结束灰度
+	CancelGrayVersion(ctx context.Context, biz *CancelGrayVersionBiz, opts ...ValueOptions) error
+	// UploadVersion 小程序基于模板上传版本
+	UploadVersion(ctx context.Context, biz *UploadVersionBiz, opts ...ValueOptions) error
+	// QueryVersionDetail 小程序版本详情查询
+	QueryVersionDetail(ctx context.Context, biz *QueryVersionDetailBiz, opts ...ValueOptions) (*VersionDetail, error)
+	// QueryVersionBuild 小程序查询版本构建状态
+	QueryVersionBuild(ctx context.Context, biz *QueryVersionBuildBiz, opts ...ValueOptions) (*QueryVersionBuildResp, error)
+}
+
+var (
+	_ AppAPI  = (*AppService)(nil)
+	_ MiniAPI = (*MiniService)(nil)
+)
@@ -10,8 +10,18 @@ type AppService service
 
 // CreateAppMemberBiz 应用添加成员
 type CreateAppMemberBiz struct {
-	LogonID string `json:"logon_id"` // 支付宝登录账号ID
-	Role    string `json:"role"`     //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+	LogonID string     `json:"logon_id"` // 支付宝登录账号ID
+	Role    MemberRole `json:"role"`     //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+}
+
+// Validate 校验参数
+func (b *CreateAppMemberBiz) Validate() error {
+	v := newValidation(b)
+	v.required("logon_id", b.LogonID)
+	if v.required("role", string(b.Role)) {
+		v.oneOf("role", string(b.Role), memberRoles...)
+	}
+	return v.err()
 }
 
 // CreateMember 应用添加成员，目前只支持小程序类型的应用使用
@@ -30,8 +40,18 @@ func (s *AppService) CreateMember(ctx context.Context, biz *CreateAppMemberBiz,
 
 // DeleteMemberBiz 应用删除成员
 type DeleteMemberBiz struct {
-	UserID string `json:"user_id"` // 蚂蚁统一会员ID
-	Role   string `json:"role"`    //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+	UserID string     `json:"user_id"` // 蚂蚁统一会员ID
+	Role   MemberRole `json:"role"`    //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+}
+
+// Validate 校验参数
+func (b *DeleteMemberBiz) Validate() error {
+	v := newValidation(b)
+	v.required("user_id", b.UserID)
+	if v.required("role", string(b.Role)) {
+		v.oneOf("role", string(b.Role), memberRoles...)
+	}
+	return v.err()
 }
 
 // DeleteMember 应用删除成员，目前只支持小程序类型的应用使用
@@ -50,19 +70,26 @@ func (s *AppService) DeleteMember(ctx context.Context, biz *DeleteMemberBiz, opt
 
 // QueryAppMembersBiz 应用查询成员列表
 type QueryAppMembersBiz struct {
-	Role string `json:"role"` //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+	Role MemberRole `json:"role"` //成员的角色类型，DEVELOPER-开发者，EXPERIENCER-体验者
+}
+
+// Validate 校验参数
+func (b *QueryAppMembersBiz) Validate() error {
+	v := newValidation(b)
+	v.oneOf("role", string(b.Role), memberRoles...)
+	return v.err()
 }
 
 // AppMemberInfo 小程序成员模型
 type AppMemberInfo struct {
-	UserID    string `json:"user_id"`
-	NickName  string `json:"nick_name"`
-	Portrait  string `json:"portrait"`
-	Status    string `json:"status"`
-	GmtJoin   string `json:"gmt_join"`
-	LogonID   string `json:"logon_id"`
-	GmtInvite string `json:"gmt_invite"`
-	Role      string `json:"role"`
+	UserID    string     `json:"user_id"`
+	NickName  string     `json:"nick_name"`
+	Portrait  string     `json:"portrait"`
+	Status    string     `json:"status"`
+	GmtJoin   Time       `json:"gmt_join"`
+	LogonID   string     `json:"logon_id"`
+	GmtInvite Time       `json:"gmt_invite"`
+	Role      MemberRole `json:"role"`
 }
 
 // QueryAppMembersResp 成员列表
@@ -97,6 +124,14 @@ type CreateAppQRCodeBiz struct {
 	Describe   string `json:"describe"`    //对应的二维码描述。
 }
 
+// Validate 校验参数
+func (b *CreateAppQRCodeBiz) Validate() error {
+	v := newValidation(b)
+	v.required("url_param", b.URLParam)
+	v.required("describe", b.Describe)
+	return v.err()
+}
+
 // CreateAppQRCode 生成小程序推广二维码
 func (s *AppService) CreateAppQRCode(ctx context.Context, biz *CreateAppQRCodeBiz, opts ...ValueOptions) (*CreateAppQRCodeResp, error) {
 	apiMethod := "alipay.open.app.qrcode.create"
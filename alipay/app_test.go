@@ -138,9 +138,9 @@ func TestAppService_QueryAppMembers(t *testing.T) {
 			NickName:  "张三",
 			Portrait:  "http://imageg.alipay.com/1",
 			Status:    "VALID",
-			GmtJoin:   "2017-08-12",
+			GmtJoin:   mustParseTime("2017-08-12"),
 			LogonID:   "test@e*****e.com",
-			GmtInvite: "2017-09-08 12:00:00",
+			GmtInvite: mustParseTime("2017-09-08 12:00:00"),
 			Role:      "DEVELOPER",
 		},
 	}}
@@ -0,0 +1,128 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditProblem 提交审核前发现的问题
+type AuditProblem struct {
+	Field      string          // 缺少或不合法的字段的json名称
+	Message    string          // 问题描述
+	Categories []*CategoryNode // 提出该要求的类目，与类目无关时为空
+}
+
+func (p *AuditProblem) String() string {
+	if len(p.Categories) == 0 {
+		return p.Field + ": " + p.Message
+	}
+	names := make([]string, len(p.Categories))
+	for i, node := range p.Categories {
+		names[i] = strings.Join(node.NamePath(), "/")
+	}
+	return fmt.Sprintf("%v: %v (required by %v)", p.Field, p.Message, strings.Join(names, ", "))
+}
+
+// CheckAudit 根据类目数据检查提交审核的参数，返回发现的全部问题
+//
+// 除了Validate的校验外，还会检查：
+//   - 选择的类目存在且为最后一级类目
+//   - 类目需要营业执照时，first_license_pic、license_no和license_valid_date不能为空
+//   - 类目需要门头照时，out_door_pic不能为空
+//   - 类目需要特殊资质时，first_special_license_pic不能为空
+//   - region_type为LOCATION时，service_region_info的省市区代码和名称一致，见ValidateRegionInfo
+//   - 至少上传一张截图，测试账号和密码需要同时提供
+//
+// MiniCategoryIDs为空时按AppCategoryIDs检查，两者都为空时沿用小程序已有的类目，不检查类目要求。
+// biz为nil时只返回biz缺失的问题，选择了类目但categories为nil时返回categories缺失的问题并跳过类目检查。
+func CheckAudit(biz *ApplyVersionAuditBiz, categories *QueryCategoryResp) []*AuditProblem {
+	var problems []*AuditProblem
+	add := func(field, message string, nodes ...*CategoryNode) {
+		problems = append(problems, &AuditProblem{Field: field, Message: message, Categories: nodes})
+	}
+	if biz == nil {
+		add("biz", "is required")
+		return problems
+	}
+	if verr, ok := biz.Validate().(*ValidationError); ok {
+		for _, fe := range verr.Errors {
+			add(fe.Field, fe.Message)
+		}
+	}
+
+	field, ids := "mini_category_ids", biz.MiniCategoryIDs
+	if ids == "" {
+		field, ids = "app_category_ids", biz.AppCategoryIDs
+	}
+	if ids != "" && categories == nil {
+		add("categories", "is required")
+	} else if ids != "" {
+		tree := categories.MiniCategoryTree()
+		if field == "app_category_ids" {
+			tree = categories.AppCategoryTree()
+		}
+		selection := tree.SelectIDs(ids)
+		if verr, ok := selection.Validate().(*ValidationError); ok {
+			for _, fe := range verr.Errors {
+				add(field, fe.Error())
+			}
+		}
+		req := selection.Requirements()
+		if len(req.License) > 0 {
+			if biz.FirstLicensePic == nil {
+				add("first_license_pic", "license picture is required", req.License...)
+			}
+			if biz.LicenseNo == "" {
+				add("license_no", "license number is required", req.License...)
+			}
+			if biz.LicenseValidDate == "" {
+				add("license_valid_date", "license valid date is required", req.License...)
+			}
+		}
+		if len(req.OutDoorPic) > 0 && biz.OutDoorPic == nil {
+			add("out_door_pic", "outdoor picture is required", req.OutDoorPic...)
+		}
+		if len(req.SpecialLicense) > 0 && biz.FirstSpecialLicensePic == nil {
+			add("first_special_license_pic", "special license picture is required", req.SpecialLicense...)
+		}
+	}
+
+	if biz.RegionType == "LOCATION" {
+		if verr, ok := ValidateRegionInfo(biz.ServiceRegionInfo...).(*ValidationError); ok {
+			for _, fe := range verr.Errors {
+				add(fe.Field, fe.Message)
+			}
+		}
+	}
+	if biz.LicenseValidDate != "" {
+		if _, err := time.Parse(dateLayout, biz.LicenseValidDate); err != nil {
+			add("license_valid_date", fmt.Sprintf("%q is not in yyyy-MM-dd format", biz.LicenseValidDate))
+		}
+	}
+	if biz.FirstScreenShot == nil && biz.SecondScreenShot == nil && biz.ThirdScreenShot == nil &&
+		biz.FourthScreenShot == nil && biz.FifthScreenShot == nil {
+		add("first_screen_shot", "at least one screenshot is required")
+	}
+	if (biz.TestAccount == "") != (biz.TestPassword == "") {
+		add("test_accout", "test account and test password must be provided together")
+	}
+	return problems
+}
+
+// PrepareAudit 查询类目数据并检查提交审核的参数，见PrepareAudit函数
+func (s *MiniService) PrepareAudit(ctx context.Context, biz *ApplyVersionAuditBiz, opts ...ValueOptions) ([]*AuditProblem, error) {
+	return PrepareAudit(ctx, s, biz, opts...)
+}
+
+// PrepareAudit 通过mini查询类目数据并检查提交审核的参数，返回发现的问题，没有问题时返回空列表
+//
+// 只有查询类目失败时返回错误，检查规则见CheckAudit。
+func PrepareAudit(ctx context.Context, mini MiniAPI, biz *ApplyVersionAuditBiz, opts ...ValueOptions) ([]*AuditProblem, error) {
+	categories, err := mini.QueryCategory(ctx, &QueryCategoryBiz{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return CheckAudit(biz, categories), nil
+}
@@ -0,0 +1,150 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func auditFields(problems []*AuditProblem) []string {
+	var fields []string
+	for _, p := range problems {
+		fields = append(fields, p.Field)
+	}
+	return fields
+}
+
+func TestCheckAudit(t *testing.T) {
+	categories := &QueryCategoryResp{MiniCategoryList: []*MiniAppCategory{
+		{CategoryID: "1", CategoryName: "生活服务", ParentCategoryID: "0", HasChild: true},
+		{CategoryID: "11", CategoryName: "家政", ParentCategoryID: "1", NeedLicense: true, NeedOutDoorPic: true},
+		{CategoryID: "2", CategoryName: "医疗", ParentCategoryID: "0", HasChild: true, NeedSpecialLicense: true},
+		{CategoryID: "21", CategoryName: "药店", ParentCategoryID: "2"},
+	}}
+	biz := &ApplyVersionAuditBiz{
+		AppVersion:       "0.0.1",
+		VersionDesc:      "版本描述",
+		MiniCategoryIDs:  "1_11;2_21",
+		LicenseValidDate: "2099/12/31",
+		TestAccount:      "test",
+	}
+
+	problems := CheckAudit(biz, categories)
+	want := []string{"first_license_pic", "license_no", "out_door_pic", "first_special_license_pic",
+		"license_valid_date", "first_screen_shot", "test_accout"}
+	if got := auditFields(problems); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CheckAudit got %v, want %v", got, want)
+	}
+	if got := problems[0].String(); got != "first_license_pic: license picture is required (required by 生活服务/家政)" {
+		t.Errorf("AuditProblem.String got %v", got)
+	}
+
+	biz.FirstLicensePic = FileFromBytes("license.png", []byte("1"))
+	biz.LicenseNo = "91330100000000000X"
+	biz.LicenseValidDate = "2099-12-31"
+	biz.OutDoorPic = FileFromBytes("door.png", []byte("1"))
+	biz.FirstSpecialLicensePic = FileFromBytes("special.png", []byte("1"))
+	biz.SecondScreenShot = FileFromBytes("2.png", []byte("1"))
+	biz.TestPassword = "secret"
+	if problems := CheckAudit(biz, categories); len(problems) != 0 {
+		t.Errorf("CheckAudit got %v, want no problems", auditFields(problems))
+	}
+
+	biz.RegionType = "LOCATION"
+	biz.ServiceRegionInfo = []*RegionInfo{{ProvinceCode: "330000", ProvinceName: "浙江"}}
+	want = []string{"service_region_info[0].province_code"}
+	if got := auditFields(CheckAudit(biz, categories)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAudit got %v, want %v", got, want)
+	}
+
+	biz.ServiceRegionInfo[0].ProvinceName = "浙江省"
+	biz.MiniCategoryIDs = "1;3_31"
+	biz.VersionDesc = ""
+	want = []string{"version_desc", "mini_category_ids", "mini_category_ids"}
+	if got := auditFields(CheckAudit(biz, categories)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAudit got %v, want %v", got, want)
+	}
+}
+
+func TestCheckAudit_nil(t *testing.T) {
+	want := []string{"biz"}
+	if got := auditFields(CheckAudit(nil, &QueryCategoryResp{})); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAudit got %v, want %v", got, want)
+	}
+
+	biz := &ApplyVersionAuditBiz{
+		AppVersion:      "0.0.1",
+		VersionDesc:     "版本描述",
+		MiniCategoryIDs: "1_11",
+		FirstScreenShot: FileFromBytes("1.png", []byte("1")),
+	}
+	want = []string{"categories"}
+	if got := auditFields(CheckAudit(biz, nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAudit got %v, want %v", got, want)
+	}
+	biz.MiniCategoryIDs = ""
+	if problems := CheckAudit(biz, nil); len(problems) != 0 {
+		t.Errorf("CheckAudit got %v, want no problems", auditFields(problems))
+	}
+}
+
+func TestMiniService_PrepareAudit(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("method"); got != "alipay.open.mini.category.query" {
+			t.Errorf("method got %v, want alipay.open.mini.category.query", got)
+		}
+		fmt.Fprint(w, `{
+							"alipay_open_mini_category_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"mini_category_list": [
+									{
+										"category_id": "107396",
+										"category_name": "公共交通",
+										"parent_category_id": "0",
+										"has_child": false,
+										"need_out_door_pic": true
+									}
+								]
+							}
+						}`)
+	})
+
+	problems, err := client.Mini.PrepareAudit(context.Background(), &ApplyVersionAuditBiz{
+		AppVersion:      "0.0.1",
+		VersionDesc:     "版本描述",
+		MiniCategoryIDs: "107396",
+		FirstScreenShot: FileFromBytes("1.png", []byte("1")),
+	})
+	if err != nil {
+		t.Fatalf("Mini.PrepareAudit returned unexcepted error: %v", err)
+	}
+	if got := auditFields(problems); !reflect.DeepEqual(got, []string{"out_door_pic"}) {
+		t.Errorf("Mini.PrepareAudit got %v, want [out_door_pic]", got)
+	}
+}
+
+func TestMiniService_PrepareAudit_failed(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_category_query_response": {
+								"code": "20000",
+								"msg": "Service Currently Unavailable",
+								"sub_code": "isp.unknow-error",
+								"sub_msg": "系统繁忙"
+							}
+						}`)
+	})
+
+	if _, err := client.Mini.PrepareAudit(context.Background(), &ApplyVersionAuditBiz{}); err == nil {
+		t.Errorf("Mini.PrepareAudit excepted error")
+	}
+}
@@ -0,0 +1,282 @@
+package alipay
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCategories MiniCategoryIDs和AppCategoryIDs最多可以选择的类目数量
+const maxCategories = 4
+
+// CategoryNode 类目树中的类目
+type CategoryNode struct {
+	*MiniAppCategory
+	Parent   *CategoryNode
+	Children []*CategoryNode
+}
+
+// Path 返回从一级类目到当前类目的路径
+func (n *CategoryNode) Path() []*CategoryNode {
+	var path []*CategoryNode
+	for node := n; node != nil; node = node.Parent {
+		path = append([]*CategoryNode{node}, path...)
+	}
+	return path
+}
+
+// IDPath 返回下划线连接的类目ID路径，例如a_b_c
+func (n *CategoryNode) IDPath() string {
+	path := n.Path()
+	ids := make([]string, len(path))
+	for i, node := range path {
+		ids[i] = node.CategoryID
+	}
+	return strings.Join(ids, "_")
+}
+
+// NamePath 返回从一级类目到当前类目的名称
+func (n *CategoryNode) NamePath() []string {
+	path := n.Path()
+	names := make([]string, len(path))
+	for i, node := range path {
+		names[i] = node.CategoryName
+	}
+	return names
+}
+
+// descendantOf 当前类目是否为ancestor或其下级类目
+func (n *CategoryNode) descendantOf(ancestor *CategoryNode) bool {
+	for node := n; node != nil; node = node.Parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLeaf 是否为最后一级类目
+func (n *CategoryNode) IsLeaf() bool {
+	return len(n.Children) == 0 && !n.HasChild
+}
+
+// CategoryTree 由QueryCategory返回的扁平类目列表构建的类目树
+type CategoryTree struct {
+	Roots []*CategoryNode // 一级类目
+	byID  map[string]*CategoryNode
+}
+
+// NewCategoryTree 根据ParentCategoryID构建类目树，父类目不在列表中或会形成环的类目作为一级类目
+func NewCategoryTree(categories []*MiniAppCategory) *CategoryTree {
+	t := &CategoryTree{byID: make(map[string]*CategoryNode, len(categories))}
+	nodes := make([]*CategoryNode, 0, len(categories))
+	for _, c := range categories {
+		if c == nil {
+			continue
+		}
+		node := &CategoryNode{MiniAppCategory: c}
+		t.byID[c.CategoryID] = node
+		nodes = append(nodes, node)
+	}
+	for _, node := range nodes {
+		parent, ok := t.byID[node.ParentCategoryID]
+		if !ok || parent.descendantOf(node) {
+			t.Roots = append(t.Roots, node)
+			continue
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+	return t
+}
+
+// MiniCategoryTree 返回新小程序前台类目树，用于MiniCategoryIDs
+func (r *QueryCategoryResp) MiniCategoryTree() *CategoryTree {
+	return NewCategoryTree(r.MiniCategoryList)
+}
+
+// AppCategoryTree 返回老小程序类目树，用于AppCategoryIDs
+func (r *QueryCategoryResp) AppCategoryTree() *CategoryTree {
+	return NewCategoryTree(r.CategoryList)
+}
+
+// Get 按类目ID查找类目
+func (t *CategoryTree) Get(id string) (*CategoryNode, bool) {
+	node, ok := t.byID[id]
+	return node, ok
+}
+
+// Find 按从一级类目开始的名称路径查找类目，例如Find("生活服务", "家政")
+func (t *CategoryTree) Find(names ...string) (*CategoryNode, bool) {
+	if len(names) == 0 {
+		return nil, false
+	}
+	level := t.Roots
+	var found *CategoryNode
+	for _, name := range names {
+		found = nil
+		for _, node := range level {
+			if node.CategoryName == name {
+				found = node
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+		level = found.Children
+	}
+	return found, true
+}
+
+// Search 返回名称包含keyword的全部类目，按树的先序排列
+func (t *CategoryTree) Search(keyword string) []*CategoryNode {
+	var found []*CategoryNode
+	var walk func(nodes []*CategoryNode)
+	walk = func(nodes []*CategoryNode) {
+		for _, node := range nodes {
+			if strings.Contains(node.CategoryName, keyword) {
+				found = append(found, node)
+			}
+			walk(node.Children)
+		}
+	}
+	walk(t.Roots)
+	return found
+}
+
+// ParseCategoryIDs 解析a_b_c;d_e格式的类目字符串，返回每个类目的ID路径
+func ParseCategoryIDs(s string) [][]string {
+	var paths [][]string
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			paths = append(paths, strings.Split(part, "_"))
+		}
+	}
+	return paths
+}
+
+// categoryRef 选择的类目，node为nil表示类目不存在
+type categoryRef struct {
+	ref  string   // 选择时使用的ID或名称路径，用于错误信息
+	ids  []string // 选择时指定的完整ID路径，为nil时不校验
+	node *CategoryNode
+}
+
+// CategorySelection 选择的类目，用于生成MiniCategoryIDs或AppCategoryIDs
+//
+//	s := tree.Select().AddPath("生活服务", "家政").AddID("20")
+//	if err := s.Validate(); err != nil {
+//		...
+//	}
+//	biz.MiniCategoryIDs = s.String()
+type CategorySelection struct {
+	tree *CategoryTree
+	refs []*categoryRef
+}
+
+// Select 创建空的类目选择
+func (t *CategoryTree) Select() *CategorySelection {
+	return &CategorySelection{tree: t}
+}
+
+// SelectIDs 解析a_b_c;d_e格式的类目字符串，用于校验已有的类目配置
+func (t *CategoryTree) SelectIDs(s string) *CategorySelection {
+	sel := t.Select()
+	for _, ids := range ParseCategoryIDs(s) {
+		node, _ := t.Get(ids[len(ids)-1])
+		sel.refs = append(sel.refs, &categoryRef{ref: strings.Join(ids, "_"), ids: ids, node: node})
+	}
+	return sel
+}
+
+// AddID 按类目ID选择类目
+func (s *CategorySelection) AddID(id string) *CategorySelection {
+	node, _ := s.tree.Get(id)
+	s.refs = append(s.refs, &categoryRef{ref: id, node: node})
+	return s
+}
+
+// AddPath 按从一级类目开始的名称路径选择类目
+func (s *CategorySelection) AddPath(names ...string) *CategorySelection {
+	node, _ := s.tree.Find(names...)
+	s.refs = append(s.refs, &categoryRef{ref: strings.Join(names, "/"), node: node})
+	return s
+}
+
+// Categories 返回选择的类目，不存在的类目会被忽略
+func (s *CategorySelection) Categories() []*CategoryNode {
+	nodes := make([]*CategoryNode, 0, len(s.refs))
+	for _, r := range s.refs {
+		if r.node != nil {
+			nodes = append(nodes, r.node)
+		}
+	}
+	return nodes
+}
+
+// Validate 校验类目存在、为最后一级类目、没有重复且不超过四个
+func (s *CategorySelection) Validate() error {
+	v := newValidation(s)
+	v.check(len(s.refs) > 0, "categories", "is required")
+	v.check(len(s.refs) <= maxCategories, "categories", "%d categories exceeds %d", len(s.refs), maxCategories)
+	seen := make(map[*CategoryNode]bool, len(s.refs))
+	for i, r := range s.refs {
+		field := fmt.Sprintf("categories[%d]", i)
+		if r.node == nil {
+			v.check(false, field, "category %q not found", r.ref)
+			continue
+		}
+		if r.ids != nil {
+			v.check(strings.Join(r.ids, "_") == r.node.IDPath(), field, "%q does not match category path %v", r.ref, r.node.IDPath())
+		}
+		v.check(r.node.IsLeaf(), field, "category %q is not a leaf category", r.ref)
+		v.check(!seen[r.node], field, "duplicate category %q", r.ref)
+		seen[r.node] = true
+	}
+	return v.err()
+}
+
+// String 返回a_b_c;d_e格式的类目字符串，不存在的类目会被忽略
+func (s *CategorySelection) String() string {
+	nodes := s.Categories()
+	paths := make([]string, len(nodes))
+	for i, node := range nodes {
+		paths[i] = node.IDPath()
+	}
+	return strings.Join(paths, ";")
+}
+
+// CategoryRequirements 选择的类目需要提供的资质，类目或其任一上级类目需要时即需要
+type CategoryRequirements struct {
+	License        []*CategoryNode // 需要营业执照的类目
+	OutDoorPic     []*CategoryNode // 需要门头照的类目
+	SpecialLicense []*CategoryNode // 需要特殊资质的类目
+}
+
+// Any 是否需要任何资质
+func (r *CategoryRequirements) Any() bool {
+	return len(r.License) > 0 || len(r.OutDoorPic) > 0 || len(r.SpecialLicense) > 0
+}
+
+// Requirements 返回选择的类目需要提供的资质
+func (s *CategorySelection) Requirements() *CategoryRequirements {
+	r := new(CategoryRequirements)
+	for _, node := range s.Categories() {
+		var license, outDoorPic, special bool
+		for _, n := range node.Path() {
+			license = license || n.NeedLicense
+			outDoorPic = outDoorPic || n.NeedOutDoorPic
+			special = special || n.NeedSpecialLicense
+		}
+		if license {
+			r.License = append(r.License, node)
+		}
+		if outDoorPic {
+			r.OutDoorPic = append(r.OutDoorPic, node)
+		}
+		if special {
+			r.SpecialLicense = append(r.SpecialLicense, node)
+		}
+	}
+	return r
+}
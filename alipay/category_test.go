@@ -0,0 +1,134 @@
+package alipay
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testCategoryTree() *CategoryTree {
+	resp := &QueryCategoryResp{MiniCategoryList: []*MiniAppCategory{
+		{CategoryID: "1", CategoryName: "生活服务", ParentCategoryID: "0", HasChild: true},
+		{CategoryID: "11", CategoryName: "家政", ParentCategoryID: "1", NeedLicense: true},
+		{CategoryID: "12", CategoryName: "维修", ParentCategoryID: "1", HasChild: true},
+		{CategoryID: "121", CategoryName: "家电维修", ParentCategoryID: "12", NeedOutDoorPic: true},
+		{CategoryID: "2", CategoryName: "医疗", ParentCategoryID: "0", HasChild: true, NeedSpecialLicense: true},
+		{CategoryID: "21", CategoryName: "药店", ParentCategoryID: "2"},
+		{CategoryID: "3", CategoryName: "餐饮", ParentCategoryID: "0"},
+	}}
+	return resp.MiniCategoryTree()
+}
+
+func TestCategoryTree(t *testing.T) {
+	tree := testCategoryTree()
+	if len(tree.Roots) != 3 {
+		t.Fatalf("Roots got %v, want 3", len(tree.Roots))
+	}
+	node, ok := tree.Get("121")
+	if !ok || node.IDPath() != "1_12_121" || !reflect.DeepEqual(node.NamePath(), []string{"生活服务", "维修", "家电维修"}) {
+		t.Errorf("Get got %v, want 1_12_121", node)
+	}
+	if node, ok := tree.Find("生活服务", "维修", "家电维修"); !ok || node.CategoryID != "121" {
+		t.Errorf("Find got %v, want 121", node)
+	}
+	if _, ok := tree.Find("生活服务", "家电维修"); ok {
+		t.Errorf("Find got category for wrong path")
+	}
+	var ids []string
+	for _, node := range tree.Search("维修") {
+		ids = append(ids, node.CategoryID)
+	}
+	if want := []string{"12", "121"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Search got %v, want %v", ids, want)
+	}
+}
+
+func TestNewCategoryTree_cycle(t *testing.T) {
+	tree := NewCategoryTree([]*MiniAppCategory{
+		{CategoryID: "1", ParentCategoryID: "2"},
+		{CategoryID: "2", ParentCategoryID: "3"},
+		{CategoryID: "3", ParentCategoryID: "1"},
+		{CategoryID: "4", ParentCategoryID: "4"},
+	})
+	if len(tree.Roots) != 2 {
+		t.Fatalf("Roots got %v, want 2", len(tree.Roots))
+	}
+	for _, id := range []string{"1", "2", "3", "4"} {
+		node, _ := tree.Get(id)
+		if path := node.Path(); len(path) > 3 {
+			t.Errorf("Path of %v got %v categories, want at most 3", id, len(path))
+		}
+	}
+	if node, _ := tree.Get("1"); node.IDPath() != "3_2_1" {
+		t.Errorf("IDPath got %v, want 3_2_1", node.IDPath())
+	}
+}
+
+func TestCategorySelection(t *testing.T) {
+	tree := testCategoryTree()
+	s := tree.Select().AddPath("生活服务", "家政").AddID("121").AddID("21")
+	if err := s.Validate(); err != nil {
+		t.Fatalf("CategorySelection.Validate returned unexcepted error: %v", err)
+	}
+	if got := s.String(); got != "1_11;1_12_121;2_21" {
+		t.Errorf("String got %v, want 1_11;1_12_121;2_21", got)
+	}
+	biz := &ApplyVersionAuditBiz{AppVersion: "0.0.1", VersionDesc: "版本描述", MiniCategoryIDs: s.String()}
+	if err := biz.Validate(); err != nil {
+		t.Errorf("ApplyVersionAuditBiz.Validate returned unexcepted error: %v", err)
+	}
+
+	r := s.Requirements()
+	ids := func(nodes []*CategoryNode) []string {
+		var ids []string
+		for _, node := range nodes {
+			ids = append(ids, node.CategoryID)
+		}
+		return ids
+	}
+	if !r.Any() || !reflect.DeepEqual(ids(r.License), []string{"11"}) ||
+		!reflect.DeepEqual(ids(r.OutDoorPic), []string{"121"}) || !reflect.DeepEqual(ids(r.SpecialLicense), []string{"21"}) {
+		t.Errorf("Requirements got %v %v %v", ids(r.License), ids(r.OutDoorPic), ids(r.SpecialLicense))
+	}
+	if tree.Select().AddID("3").Requirements().Any() {
+		t.Errorf("Requirements of 3 got Any true")
+	}
+}
+
+func TestCategorySelection_Validate(t *testing.T) {
+	tree := testCategoryTree()
+	s := tree.Select().AddID("404").AddID("12").AddID("3").AddID("3").AddID("11")
+	var verr *ValidationError
+	if err := s.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("CategorySelection.Validate got %v, want *ValidationError", err)
+	}
+	for _, field := range []string{"categories", "categories[0]", "categories[1]", "categories[3]"} {
+		if verr.Field(field) == nil {
+			t.Errorf("CategorySelection.Validate missing error for %v in %v", field, verr)
+		}
+	}
+	if verr.Field("categories[2]") != nil || verr.Field("categories[4]") != nil {
+		t.Errorf("CategorySelection.Validate got error for valid category: %v", verr)
+	}
+	if got := s.String(); got != "1_12;3;3;1_11" {
+		t.Errorf("String got %v, want 1_12;3;3;1_11", got)
+	}
+
+	if err := tree.SelectIDs("1_11;1_12_121").Validate(); err != nil {
+		t.Errorf("SelectIDs returned unexcepted error: %v", err)
+	}
+	if err := tree.SelectIDs("2_11").Validate(); !errors.As(err, &verr) || verr.Field("categories[0]") == nil {
+		t.Errorf("SelectIDs got %v, want path mismatch error", err)
+	}
+	if err := tree.Select().Validate(); err == nil {
+		t.Errorf("CategorySelection.Validate excepted error for empty selection")
+	}
+}
+
+func TestParseCategoryIDs(t *testing.T) {
+	got := ParseCategoryIDs("1_11; 1_12_121;")
+	want := [][]string{{"1", "11"}, {"1", "12", "121"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCategoryIDs got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package alipay
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+// 熔断器状态
+const (
+	CircuitClosed   CircuitState = iota // 关闭，请求正常通过
+	CircuitOpen                         // 打开，请求直接失败
+	CircuitHalfOpen                     // 半开，仅放行探测请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitOpenError 熔断器打开时快速失败返回的错误，此时请求不会发送到支付宝
+type CircuitOpenError struct {
+	Method string    // 接口方法名
+	Until  time.Time // 建议的重试时间，打开状态下为进入半开状态的时间
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("alipay: circuit breaker open for %v until %v", e.Method, e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreaker 按接口方法熔断
+//
+// 连续出现Threshold次网络错误、HTTP 5xx或支付宝系统错误后熔断器打开，
+// OpenTimeout内该接口的请求直接返回*CircuitOpenError；之后进入半开状态，
+// 放行HalfOpenProbes个探测请求，全部成功则关闭，任一失败则重新打开。
+type CircuitBreaker struct {
+	Threshold      int           // 连续失败次数阈值，默认5
+	OpenTimeout    time.Duration // 打开状态持续时间，默认30秒
+	HalfOpenProbes int           // 半开状态下的探测请求数，默认1
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openUntil time.Time
+	probes    int // 半开状态下已放行且未结束的探测请求数
+	successes int // 半开状态下成功的探测请求数
+}
+
+// circuitOutcome 请求结果对熔断器的影响
+type circuitOutcome int
+
+const (
+	circuitSuccess circuitOutcome = iota
+	circuitFailure
+	circuitIgnore // 例如调用方主动取消，不计入成功或失败
+)
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold:   threshold,
+		OpenTimeout: openTimeout,
+	}
+}
+
+// State 返回接口方法当前的熔断器状态
+func (b *CircuitBreaker) State(method string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[method]
+	if !ok {
+		return CircuitClosed
+	}
+	return b.currentState(c, time.Now())
+}
+
+// States 返回所有调用过的接口方法的熔断器状态，可用于健康检查
+func (b *CircuitBreaker) States() map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	states := make(map[string]CircuitState, len(b.circuits))
+	for method, c := range b.circuits {
+		states[method] = b.currentState(c, now)
+	}
+	return states
+}
+
+// allow 判断请求能否发出，熔断器打开时返回*CircuitOpenError
+func (b *CircuitBreaker) allow(method string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(method)
+	now := time.Now()
+	switch b.currentState(c, now) {
+	case CircuitOpen:
+		return &CircuitOpenError{Method: method, Until: c.openUntil}
+	case CircuitHalfOpen:
+		if c.state == CircuitOpen {
+			c.state = CircuitHalfOpen
+			c.probes = 0
+			c.successes = 0
+		}
+		if c.probes >= b.halfOpenProbes() {
+			// 探测请求未结束，探测失败时熔断器会重新打开OpenTimeout
+			until := c.openUntil.Add(b.openTimeout())
+			if !until.After(now) {
+				until = now.Add(b.openTimeout())
+			}
+			return &CircuitOpenError{Method: method, Until: until}
+		}
+		c.probes++
+	}
+	return nil
+}
+
+// report 记录请求结果
+func (b *CircuitBreaker) report(method string, outcome circuitOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(method)
+	switch c.state {
+	case CircuitClosed:
+		switch outcome {
+		case circuitSuccess:
+			c.failures = 0
+		case circuitFailure:
+			c.failures++
+			if c.failures >= b.threshold() {
+				b.open(c)
+			}
+		}
+	case CircuitHalfOpen:
+		if c.probes > 0 {
+			c.probes--
+		}
+		switch outcome {
+		case circuitSuccess:
+			c.successes++
+			if c.successes >= b.halfOpenProbes() {
+				c.state = CircuitClosed
+				c.failures = 0
+			}
+		case circuitFailure:
+			b.open(c)
+		}
+	}
+}
+
+func (b *CircuitBreaker) open(c *circuit) {
+	c.state = CircuitOpen
+	c.openUntil = time.Now().Add(b.openTimeout())
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+}
+
+func (b *CircuitBreaker) currentState(c *circuit, now time.Time) CircuitState {
+	if c.state == CircuitOpen && !now.Before(c.openUntil) {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+func (b *CircuitBreaker) circuit(method string) *circuit {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c, ok := b.circuits[method]
+	if !ok {
+		c = &circuit{}
+		b.circuits[method] = c
+	}
+	return c
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.Threshold <= 0 {
+		return 5
+	}
+	return b.Threshold
+}
+
+func (b *CircuitBreaker) openTimeout() time.Duration {
+	if b.OpenTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return b.OpenTimeout
+}
+
+func (b *CircuitBreaker) halfOpenProbes() int {
+	if b.HalfOpenProbes <= 0 {
+		return 1
+	}
+	return b.HalfOpenProbes
+}
+
+// isSystemError 判断是否为支付宝系统错误，业务错误和限流不计入熔断
+func isSystemError(e *ErrorResponse) bool {
+	if isThrottled(e) {
+		return false
+	}
+	return e.Code == "20000" || strings.HasPrefix(e.SubCode, "isp.")
+}
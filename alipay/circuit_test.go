@@ -0,0 +1,155 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(2, 20*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if err := b.allow("foo"); err != nil {
+			t.Fatalf("CircuitBreaker.allow returned unexcepted error: %v", err)
+		}
+		b.report("foo", circuitFailure)
+	}
+	if got := b.State("foo"); got != CircuitOpen {
+		t.Fatalf("CircuitBreaker.State got %v, want %v", got, CircuitOpen)
+	}
+	if _, ok := b.allow("foo").(*CircuitOpenError); !ok {
+		t.Errorf("CircuitBreaker.allow excepted *CircuitOpenError")
+	}
+	if err := b.allow("bar"); err != nil {
+		t.Errorf("CircuitBreaker.allow on other method returned unexcepted error: %v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if got := b.State("foo"); got != CircuitHalfOpen {
+		t.Fatalf("CircuitBreaker.State got %v, want %v", got, CircuitHalfOpen)
+	}
+	if err := b.allow("foo"); err != nil {
+		t.Fatalf("CircuitBreaker.allow probe returned unexcepted error: %v", err)
+	}
+	if err, ok := b.allow("foo").(*CircuitOpenError); !ok {
+		t.Errorf("CircuitBreaker.allow excepted only one probe in half-open state")
+	} else if !err.Until.After(time.Now()) {
+		t.Errorf("CircuitOpenError.Until got %v, want a time in the future", err.Until)
+	}
+	b.report("foo", circuitSuccess)
+	if got := b.State("foo"); got != CircuitClosed {
+		t.Errorf("CircuitBreaker.State got %v, want %v", got, CircuitClosed)
+	}
+
+	want := map[string]CircuitState{"foo": CircuitClosed, "bar": CircuitClosed}
+	got := b.States()
+	if len(got) != len(want) || got["foo"] != want["foo"] || got["bar"] != want["bar"] {
+		t.Errorf("CircuitBreaker.States got %v, want %v", got, want)
+	}
+}
+
+func TestCircuitBreaker_halfOpenFailure(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Millisecond)
+	_ = b.allow("foo")
+	b.report("foo", circuitFailure)
+	time.Sleep(15 * time.Millisecond)
+
+	if err := b.allow("foo"); err != nil {
+		t.Fatalf("CircuitBreaker.allow probe returned unexcepted error: %v", err)
+	}
+	b.report("foo", circuitFailure)
+	if got := b.State("foo"); got != CircuitOpen {
+		t.Errorf("CircuitBreaker.State got %v, want %v", got, CircuitOpen)
+	}
+}
+
+func TestClient_Do_circuitBreaker(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.CircuitBreaker = NewCircuitBreaker(2, time.Minute)
+
+	calls := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "20000",
+								"msg": "Service Currently Unavailable",
+								"sub_code": "isp.unknow-error",
+								"sub_msg": "系统繁忙"
+							}
+						}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Mini.QueryVersionList(context.Background()); err == nil {
+			t.Fatalf("Mini.QueryVersionList excepted error")
+		}
+	}
+	_, err := client.Mini.QueryVersionList(context.Background())
+	if e, ok := err.(*CircuitOpenError); !ok || e.Method != "alipay.open.mini.version.list.query" {
+		t.Errorf("Mini.QueryVersionList got %v, want *CircuitOpenError", err)
+	}
+	if calls != 2 {
+		t.Errorf("server received %d calls, want 2", calls)
+	}
+}
+
+func TestClient_Do_circuitBreakerBusinessError(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.CircuitBreaker = NewCircuitBreaker(1, time.Minute)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "40004",
+								"msg": "Business Failed",
+								"sub_code": "MINI_APP_NOT_EXIST",
+								"sub_msg": "小程序不存在"
+							}
+						}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Mini.QueryVersionList(context.Background())
+		if _, ok := err.(*ErrorResponse); !ok {
+			t.Errorf("Mini.QueryVersionList got %v, want *ErrorResponse", err)
+		}
+	}
+}
+
+func TestClient_Do_circuitBreakerAfterRateLimit(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	method := "alipay.open.mini.version.list.query"
+	client.CircuitBreaker = NewCircuitBreaker(1, time.Millisecond)
+	client.RateLimiter = NewRateLimiter(map[string]RateLimit{method: {Rate: 0.1, Burst: 1}})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"alipay_open_mini_version_list_query_response": {"code": "10000", "msg": "Success"}}`)
+	})
+
+	_ = client.RateLimiter.Wait(context.Background(), method, "")
+	_ = client.CircuitBreaker.allow(method)
+	client.CircuitBreaker.report(method, circuitFailure)
+	time.Sleep(5 * time.Millisecond)
+
+	// 限流等待期间不占用半开状态的探测名额
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		_, err := client.Mini.QueryVersionList(ctx)
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if err := client.CircuitBreaker.allow(method); err != nil {
+		t.Errorf("CircuitBreaker.allow during rate limit wait returned unexcepted error: %v", err)
+	}
+	if err := <-done; err != context.DeadlineExceeded {
+		t.Errorf("Mini.QueryVersionList got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
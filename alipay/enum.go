@@ -0,0 +1,255 @@
+package alipay
+
+import "fmt"
+
+// VersionStatus 小程序版本状态
+type VersionStatus string
+
+// 小程序版本状态
+const (
+	VersionStatusInit          VersionStatus = "INIT"            // 开发中
+	VersionStatusAuditing      VersionStatus = "AUDITING"        // 审核中
+	VersionStatusAuditReject   VersionStatus = "AUDIT_REJECT"    // 审核驳回
+	VersionStatusWaitRelease   VersionStatus = "WAIT_RELEASE"    // 待上架
+	VersionStatusBaseAuditPass VersionStatus = "BASE_AUDIT_PASS" // 准入不可营销
+	VersionStatusGray          VersionStatus = "GRAY"            // 灰度中
+	VersionStatusRelease       VersionStatus = "RELEASE"         // 已上架
+	VersionStatusOffline       VersionStatus = "OFFLINE"         // 已下架
+	VersionStatusAuditOffline  VersionStatus = "AUDIT_OFFLINE"   // 已冻结
+)
+
+var versionStatusText = map[VersionStatus]string{
+	VersionStatusInit:          "开发中",
+	VersionStatusAuditing:      "审核中",
+	VersionStatusAuditReject:   "审核驳回",
+	VersionStatusWaitRelease:   "待上架",
+	VersionStatusBaseAuditPass: "准入不可营销",
+	VersionStatusGray:          "灰度中",
+	VersionStatusRelease:       "已上架",
+	VersionStatusOffline:       "已下架",
+	VersionStatusAuditOffline:  "已冻结",
+}
+
+// ParseVersionStatus 解析版本状态
+func ParseVersionStatus(s string) (VersionStatus, error) {
+	if _, ok := versionStatusText[VersionStatus(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown version status %q", s)
+	}
+	return VersionStatus(s), nil
+}
+
+// String 返回状态的中文描述，未知状态返回原值
+func (s VersionStatus) String() string {
+	if text, ok := versionStatusText[s]; ok {
+		return text
+	}
+	return string(s)
+}
+
+// IsTerminal 审核流程已经结束，状态不会再自动变化
+func (s VersionStatus) IsTerminal() bool {
+	switch s {
+	case VersionStatusAuditReject, VersionStatusWaitRelease, VersionStatusBaseAuditPass,
+		VersionStatusRelease, VersionStatusOffline, VersionStatusAuditOffline:
+		return true
+	}
+	return false
+}
+
+// IsOnline 版本已上架或灰度中
+func (s VersionStatus) IsOnline() bool {
+	return s == VersionStatusRelease || s == VersionStatusGray
+}
+
+// ExperiencePackageStatus 体验版打包状态
+type ExperiencePackageStatus string
+
+// 体验版打包状态
+const (
+	ExpVersionPackaged  ExperiencePackageStatus = "expVersionPackaged"  // 体验版打包成功
+	ExpVersionPackaging ExperiencePackageStatus = "expVersionPackaging" // 体验版打包中
+	NotExpVersion       ExperiencePackageStatus = "notExpVersion"       // 非体验版
+)
+
+var experiencePackageStatusText = map[ExperiencePackageStatus]string{
+	ExpVersionPackaged:  "体验版打包成功",
+	ExpVersionPackaging: "体验版打包中",
+	NotExpVersion:       "非体验版",
+}
+
+// ParseExperiencePackageStatus 解析体验版打包状态
+func ParseExperiencePackageStatus(s string) (ExperiencePackageStatus, error) {
+	if _, ok := experiencePackageStatusText[ExperiencePackageStatus(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown experience status %q", s)
+	}
+	return ExperiencePackageStatus(s), nil
+}
+
+// String 返回状态的中文描述，未知状态返回原值
+func (s ExperiencePackageStatus) String() string {
+	if text, ok := experiencePackageStatusText[s]; ok {
+		return text
+	}
+	return string(s)
+}
+
+// IsTerminal 打包已经结束
+func (s ExperiencePackageStatus) IsTerminal() bool {
+	return s == ExpVersionPackaged || s == NotExpVersion
+}
+
+// CreateStatus 版本构建状态
+type CreateStatus string
+
+// 版本构建状态
+const (
+	CreateStatusQueued       CreateStatus = "0" // 构建排队中
+	CreateStatusBuilding     CreateStatus = "1" // 正在构建
+	CreateStatusBuildSuccess CreateStatus = "2" // 构建成功
+	CreateStatusBuildFailed  CreateStatus = "3" // 构建失败
+	CreateStatusBuildTimeout CreateStatus = "5" // 构建超时
+	CreateStatusCreated      CreateStatus = "6" // 版本创建成功
+)
+
+var createStatusText = map[CreateStatus]string{
+	CreateStatusQueued:       "构建排队中",
+	CreateStatusBuilding:     "正在构建",
+	CreateStatusBuildSuccess: "构建成功",
+	CreateStatusBuildFailed:  "构建失败",
+	CreateStatusBuildTimeout: "构建超时",
+	CreateStatusCreated:      "版本创建成功",
+}
+
+// ParseCreateStatus 解析版本构建状态
+func ParseCreateStatus(s string) (CreateStatus, error) {
+	if _, ok := createStatusText[CreateStatus(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown create status %q", s)
+	}
+	return CreateStatus(s), nil
+}
+
+// String 返回状态的中文描述，未知状态返回原值
+func (s CreateStatus) String() string {
+	if text, ok := createStatusText[s]; ok {
+		return text
+	}
+	return string(s)
+}
+
+// Failed 构建失败或超时
+func (s CreateStatus) Failed() bool {
+	return s == CreateStatusBuildFailed || s == CreateStatusBuildTimeout
+}
+
+// Succeeded 版本创建成功
+func (s CreateStatus) Succeeded() bool {
+	return s == CreateStatusCreated
+}
+
+// IsTerminal 构建已经结束，不需要继续轮询
+func (s CreateStatus) IsTerminal() bool {
+	return s.Failed() || s.Succeeded()
+}
+
+// MemberRole 小程序成员角色
+type MemberRole string
+
+// 小程序成员角色
+const (
+	MemberRoleDeveloper   MemberRole = "DEVELOPER"   // 开发者
+	MemberRoleExperiencer MemberRole = "EXPERIENCER" // 体验者
+)
+
+var memberRoleText = map[MemberRole]string{
+	MemberRoleDeveloper:   "开发者",
+	MemberRoleExperiencer: "体验者",
+}
+
+// ParseMemberRole 解析成员角色
+func ParseMemberRole(s string) (MemberRole, error) {
+	if _, ok := memberRoleText[MemberRole(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown member role %q", s)
+	}
+	return MemberRole(s), nil
+}
+
+// String 返回角色的中文描述，未知角色返回原值
+func (r MemberRole) String() string {
+	if text, ok := memberRoleText[r]; ok {
+		return text
+	}
+	return string(r)
+}
+
+// GrayStrategy 小程序灰度策略，代表百分之多少的用户
+type GrayStrategy string
+
+// 小程序灰度策略
+const (
+	GrayStrategyP10 GrayStrategy = "p10" // 10%的用户
+	GrayStrategyP30 GrayStrategy = "p30" // 30%的用户
+	GrayStrategyP50 GrayStrategy = "p50" // 50%的用户
+)
+
+var grayStrategyPercent = map[GrayStrategy]int{
+	GrayStrategyP10: 10,
+	GrayStrategyP30: 30,
+	GrayStrategyP50: 50,
+}
+
+// ParseGrayStrategy 解析灰度策略
+func ParseGrayStrategy(s string) (GrayStrategy, error) {
+	if _, ok := grayStrategyPercent[GrayStrategy(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown gray strategy %q", s)
+	}
+	return GrayStrategy(s), nil
+}
+
+// String 返回灰度比例描述，未知策略返回原值
+func (g GrayStrategy) String() string {
+	if p, ok := grayStrategyPercent[g]; ok {
+		return fmt.Sprintf("%d%%用户", p)
+	}
+	return string(g)
+}
+
+// Percent 灰度的用户百分比，未知策略返回0
+func (g GrayStrategy) Percent() int {
+	return grayStrategyPercent[g]
+}
+
+// BundleID 小程序客户端类型
+type BundleID string
+
+// 小程序客户端类型
+const (
+	BundleIDAlipay    BundleID = "com.alipay.alipaywallet"          // 支付宝端
+	BundleIDDingTalk  BundleID = "com.alibaba.android.rimet"        // DINGDING端
+	BundleIDAmap      BundleID = "com.amap.app"                     // 高德端
+	BundleIDGenie     BundleID = "com.alibaba.ailabs.genie.webapps" // 天猫精灵端
+	BundleIDAlipayIoT BundleID = "com.alipay.iot.xpaas"             // 支付宝IOT
+)
+
+var bundleIDText = map[BundleID]string{
+	BundleIDAlipay:    "支付宝端",
+	BundleIDDingTalk:  "DINGDING端",
+	BundleIDAmap:      "高德端",
+	BundleIDGenie:     "天猫精灵端",
+	BundleIDAlipayIoT: "支付宝IOT",
+}
+
+// ParseBundleID 解析客户端类型
+func ParseBundleID(s string) (BundleID, error) {
+	if _, ok := bundleIDText[BundleID(s)]; !ok {
+		return "", fmt.Errorf("alipay: unknown bundle id %q", s)
+	}
+	return BundleID(s), nil
+}
+
+// String 返回客户端的中文描述，未知客户端返回原值
+func (b BundleID) String() string {
+	if text, ok := bundleIDText[b]; ok {
+		return text
+	}
+	return string(b)
+}
@@ -0,0 +1,89 @@
+package alipay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVersionStatus(t *testing.T) {
+	s, err := ParseVersionStatus("AUDIT_REJECT")
+	if err != nil {
+		t.Fatalf("ParseVersionStatus returned unexcepted error: %v", err)
+	}
+	if s != VersionStatusAuditReject || s.String() != "审核驳回" || !s.IsTerminal() {
+		t.Errorf("ParseVersionStatus got %v", s)
+	}
+	if VersionStatusAuditing.IsTerminal() || VersionStatusInit.IsTerminal() || VersionStatusGray.IsTerminal() {
+		t.Errorf("IsTerminal got true for non-terminal status")
+	}
+	if !VersionStatusGray.IsOnline() || VersionStatusOffline.IsOnline() {
+		t.Errorf("IsOnline got unexcepted result")
+	}
+	if _, err := ParseVersionStatus("UNKNOWN"); err == nil {
+		t.Errorf("ParseVersionStatus excepted error")
+	}
+	if got := VersionStatus("UNKNOWN").String(); got != "UNKNOWN" {
+		t.Errorf("String got %v, want UNKNOWN", got)
+	}
+}
+
+func TestCreateStatus(t *testing.T) {
+	tests := []struct {
+		status                      CreateStatus
+		failed, succeeded, terminal bool
+	}{
+		{CreateStatusQueued, false, false, false},
+		{CreateStatusBuilding, false, false, false},
+		{CreateStatusBuildSuccess, false, false, false},
+		{CreateStatusBuildFailed, true, false, true},
+		{CreateStatusBuildTimeout, true, false, true},
+		{CreateStatusCreated, false, true, true},
+	}
+	for _, tt := range tests {
+		if tt.status.Failed() != tt.failed || tt.status.Succeeded() != tt.succeeded || tt.status.IsTerminal() != tt.terminal {
+			t.Errorf("CreateStatus %v predicates got %v %v %v", tt.status,
+				tt.status.Failed(), tt.status.Succeeded(), tt.status.IsTerminal())
+		}
+		if s, err := ParseCreateStatus(string(tt.status)); err != nil || s != tt.status {
+			t.Errorf("ParseCreateStatus got %v, %v", s, err)
+		}
+	}
+	if _, err := ParseCreateStatus("4"); err == nil {
+		t.Errorf("ParseCreateStatus excepted error")
+	}
+}
+
+func TestEnums_parse(t *testing.T) {
+	if s, err := ParseExperiencePackageStatus("expVersionPackaged"); err != nil || !s.IsTerminal() || s.String() != "体验版打包成功" {
+		t.Errorf("ParseExperiencePackageStatus got %v, %v", s, err)
+	}
+	if ExpVersionPackaging.IsTerminal() {
+		t.Errorf("IsTerminal got true for packaging")
+	}
+	if r, err := ParseMemberRole("EXPERIENCER"); err != nil || r.String() != "体验者" {
+		t.Errorf("ParseMemberRole got %v, %v", r, err)
+	}
+	if g, err := ParseGrayStrategy("p30"); err != nil || g.Percent() != 30 || g.String() != "30%用户" {
+		t.Errorf("ParseGrayStrategy got %v, %v", g, err)
+	}
+	if _, err := ParseGrayStrategy("p20"); err == nil {
+		t.Errorf("ParseGrayStrategy excepted error")
+	}
+	if b, err := ParseBundleID("com.amap.app"); err != nil || b != BundleIDAmap || b.String() != "高德端" {
+		t.Errorf("ParseBundleID got %v, %v", b, err)
+	}
+}
+
+func TestEnums_json(t *testing.T) {
+	var detail VersionDetail
+	if err := json.Unmarshal([]byte(`{"status":"RELEASE","gray_strategy":"p10"}`), &detail); err != nil {
+		t.Fatalf("json.Unmarshal returned unexcepted error: %v", err)
+	}
+	if detail.Status != VersionStatusRelease || detail.GrayStrategy != GrayStrategyP10 {
+		t.Errorf("json.Unmarshal got %+v", detail)
+	}
+	data, _ := json.Marshal(&OnlineVersionBiz{AppVersion: "0.0.1", BundleID: BundleIDAlipay})
+	if want := `{"app_version":"0.0.1","bundle_id":"com.alipay.alipaywallet"}`; string(data) != want {
+		t.Errorf("json.Marshal got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,248 @@
+package alipay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// File wrapped file content
+//
+// 可以直接构造File并设置Content，也可以使用FileFromBytes、FileFromPath、FileFromURL创建。
+// 通过构造函数创建的File每次发送请求时都会从头读取，同一个biz可以在失败后重新提交，也可以同时发送。
+type File struct {
+	Name    string
+	Content io.Reader
+
+	data        []byte // FileFromBytes和FileFromURL的内容
+	path        string // FileFromPath的文件路径
+	size        int64
+	contentType string
+}
+
+// Read proxy Content Read
+func (f File) Read(p []byte) (n int, err error) {
+	return f.Content.Read(p)
+}
+
+// FileFromBytes 使用内存中的内容创建文件
+func FileFromBytes(name string, data []byte) *File {
+	return &File{
+		Name:        name,
+		Content:     bytes.NewReader(data),
+		data:        data,
+		size:        int64(len(data)),
+		contentType: detectContentType(name, head(data)),
+	}
+}
+
+// FileFromPath 使用本地文件创建文件，文件在发送请求时才打开，读取完毕后关闭
+func FileFromPath(filename string) (*File, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("alipay: %v is not a regular file", filename)
+	}
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	name := filepath.Base(filename)
+	return &File{
+		Name:        name,
+		Content:     &pathReader{path: filename},
+		path:        filename,
+		size:        info.Size(),
+		contentType: detectContentType(name, buf[:n]),
+	}, nil
+}
+
+// DefaultMaxDownloadSize FileFromURL默认的下载大小上限，10MB
+const DefaultMaxDownloadSize = 10 << 20
+
+// FileFromURL 下载远程文件到内存并创建文件，httpClient为nil时使用http.DefaultClient
+//
+// maxSize为下载大小上限，单位字节，不大于0时使用DefaultMaxDownloadSize，超过时返回错误。
+func FileFromURL(ctx context.Context, httpClient *http.Client, rawurl string, maxSize int64) (*File, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("alipay: download %v: %v", rawurl, resp.Status)
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDownloadSize
+	}
+	tooLarge := fmt.Errorf("alipay: download %v exceeds %d bytes", rawurl, maxSize)
+	if resp.ContentLength > maxSize {
+		return nil, tooLarge
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, tooLarge
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	f := FileFromBytes(name, data)
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		f.contentType == "application/octet-stream" && mediaType != "application/octet-stream" {
+		f.contentType = mediaType
+	}
+	return f, nil
+}
+
+// Size 文件大小，单位字节，无法预先获取时返回-1
+func (f *File) Size() int64 {
+	if f.data != nil || f.path != "" {
+		return f.size
+	}
+	return readerSize(f.Content)
+}
+
+// ContentType 探测文件的MIME类型，无法探测内容时按扩展名判断
+func (f *File) ContentType() string {
+	if f.contentType != "" {
+		return f.contentType
+	}
+	if s, ok := f.Content.(io.ReadSeeker); ok {
+		if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+			buf := make([]byte, sniffLen)
+			n, _ := io.ReadFull(s, buf)
+			if _, err = s.Seek(offset, io.SeekStart); err == nil {
+				return detectContentType(f.Name, buf[:n])
+			}
+		}
+	}
+	return detectContentType(f.Name, nil)
+}
+
+// Rewind 将文件回到开头以便再次读取，Content不支持Seek时返回错误
+func (f *File) Rewind() error {
+	if f.owned() {
+		if r, ok := f.Content.(*pathReader); ok {
+			r.Close()
+		}
+		f.Content = f.reader()
+		return nil
+	}
+	s, ok := f.Content.(io.Seeker)
+	if !ok {
+		return fmt.Errorf("alipay: file %q cannot be rewound", f.Name)
+	}
+	_, err := s.Seek(0, io.SeekStart)
+	return err
+}
+
+// reader 返回从头读取内容的新Reader，不修改Content，只能用于owned的文件
+//
+// 每个请求体使用各自的Reader，同一个File可以被多个请求同时发送。
+func (f *File) reader() io.Reader {
+	if f.data != nil {
+		return bytes.NewReader(f.data)
+	}
+	return &pathReader{path: f.path}
+}
+
+// owned 内容由File自己管理，每次发送请求都可以从头读取
+func (f *File) owned() bool {
+	return f.data != nil || f.path != ""
+}
+
+// readerSize 不消耗内容地获取剩余长度，无法获取时返回-1
+func readerSize(r io.Reader) int64 {
+	if l, ok := r.(interface{ Len() int }); ok {
+		return int64(l.Len())
+	}
+	if s, ok := r.(io.Seeker); ok {
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err = s.Seek(offset, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - offset
+	}
+	return -1
+}
+
+func head(data []byte) []byte {
+	if len(data) > sniffLen {
+		return data[:sniffLen]
+	}
+	return data
+}
+
+// pathReader 第一次读取时打开文件，读到结尾后关闭
+type pathReader struct {
+	path string
+	f    *os.File
+	done bool
+}
+
+func (r *pathReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	if r.f == nil {
+		f, err := os.Open(r.path)
+		if err != nil {
+			return 0, err
+		}
+		r.f = f
+	}
+	n, err := r.f.Read(p)
+	if err == io.EOF {
+		r.Close()
+		r.done = true
+	}
+	return n, err
+}
+
+// Close 关闭已打开的文件
+func (r *pathReader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
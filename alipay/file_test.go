@@ -0,0 +1,301 @@
+package alipay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileFromBytes(t *testing.T) {
+	f := FileFromBytes("logo", pngHeader)
+	if got := f.Size(); got != int64(len(pngHeader)) {
+		t.Errorf("Size got %v, want %v", got, len(pngHeader))
+	}
+	if got := f.ContentType(); got != "image/png" {
+		t.Errorf("ContentType got %v, want image/png", got)
+	}
+	first, _ := ioutil.ReadAll(f)
+	if err := f.Rewind(); err != nil {
+		t.Fatalf("Rewind returned unexcepted error: %v", err)
+	}
+	second, _ := ioutil.ReadAll(f)
+	if !bytes.Equal(first, pngHeader) || !bytes.Equal(second, pngHeader) {
+		t.Errorf("read got %q and %q, want %q", first, second, pngHeader)
+	}
+}
+
+func TestFileFromPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alipay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "account.txt")
+	if err := ioutil.WriteFile(name, []byte("account"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := FileFromPath(name)
+	if err != nil {
+		t.Fatalf("FileFromPath returned unexcepted error: %v", err)
+	}
+	if f.Name != "account.txt" || f.Size() != 7 {
+		t.Errorf("FileFromPath got name %v size %v, want account.txt 7", f.Name, f.Size())
+	}
+	if got := f.ContentType(); got != "text/plain; charset=utf-8" {
+		t.Errorf("ContentType got %v, want text/plain; charset=utf-8", got)
+	}
+	for i := 0; i < 2; i++ {
+		data, _ := ioutil.ReadAll(f)
+		if string(data) != "account" {
+			t.Errorf("read %d got %q, want account", i, data)
+		}
+		if err := f.Rewind(); err != nil {
+			t.Fatalf("Rewind returned unexcepted error: %v", err)
+		}
+	}
+
+	if _, err := FileFromPath(dir); err == nil {
+		t.Errorf("FileFromPath excepted error for directory")
+	}
+	if _, err := FileFromPath(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("FileFromPath excepted error for missing file")
+	}
+}
+
+func TestFileFromURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/static/logo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngHeader)
+	})
+	mux.HandleFunc("/static/data", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte{0x00, 0x01})
+	})
+	mux.HandleFunc("/static/chunked", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			w.Write(pngHeader)
+			w.(http.Flusher).Flush()
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f, err := FileFromURL(context.Background(), nil, server.URL+"/static/logo", 0)
+	if err != nil {
+		t.Fatalf("FileFromURL returned unexcepted error: %v", err)
+	}
+	if f.Name != "logo" || f.Size() != int64(len(pngHeader)) || f.ContentType() != "image/png" {
+		t.Errorf("FileFromURL got %v %v %v", f.Name, f.Size(), f.ContentType())
+	}
+
+	f, err = FileFromURL(context.Background(), server.Client(), server.URL+"/static/data", 0)
+	if err != nil {
+		t.Fatalf("FileFromURL returned unexcepted error: %v", err)
+	}
+	if got := f.ContentType(); got != "application/pdf" {
+		t.Errorf("ContentType got %v, want application/pdf from response header", got)
+	}
+
+	if _, err := FileFromURL(context.Background(), nil, server.URL+"/missing", 0); err == nil {
+		t.Errorf("FileFromURL excepted error for 404")
+	}
+	if _, err := FileFromURL(context.Background(), nil, server.URL+"/static/logo", int64(len(pngHeader))-1); err == nil {
+		t.Errorf("FileFromURL excepted error for file exceeding maxSize")
+	}
+	if _, err := FileFromURL(context.Background(), nil, server.URL+"/static/chunked", 10); err == nil {
+		t.Errorf("FileFromURL excepted error for chunked file exceeding maxSize")
+	}
+}
+
+func TestFile_Rewind(t *testing.T) {
+	f := &File{Name: "1.png", Content: bytes.NewReader(pngHeader)}
+	if got := f.Size(); got != int64(len(pngHeader)) {
+		t.Errorf("Size got %v, want %v", got, len(pngHeader))
+	}
+	if got := f.ContentType(); got != "image/png" {
+		t.Errorf("ContentType got %v, want image/png", got)
+	}
+	ioutil.ReadAll(f)
+	if err := f.Rewind(); err != nil {
+		t.Errorf("Rewind returned unexcepted error: %v", err)
+	}
+
+	f = &File{Name: "account.txt", Content: strings.NewReader("x")}
+	f.Content = ioutil.NopCloser(f.Content)
+	if f.Size() != -1 {
+		t.Errorf("Size got %v, want -1 for unknown size", f.Size())
+	}
+	if err := f.Rewind(); err == nil {
+		t.Errorf("Rewind excepted error for non-seekable content")
+	}
+}
+
+func TestClient_NewRequest_resubmit(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var received []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm returned unexcepted error: %v", err)
+		}
+		f, header, _ := r.FormFile("app_logo")
+		data, _ := ioutil.ReadAll(f)
+		received = append(received, fmt.Sprintf("%s:%s:%s", header.Filename, header.Header.Get("Content-Type"), data))
+		w.Write([]byte(`{"alipay_open_mini_baseinfo_modify_response":{"code":"10000","msg":"Success"}}`))
+	})
+
+	biz := &ModifyBaseInfoBiz{AppLogo: FileFromBytes("logo.png", pngHeader)}
+	for i := 0; i < 2; i++ {
+		req, err := client.NewRequest("alipay.open.mini.baseinfo.modify", biz)
+		if err != nil {
+			t.Fatalf("NewRequest returned unexcepted error: %v", err)
+		}
+		if req.ContentLength < 0 {
+			t.Errorf("ContentLength got %v, want known length", req.ContentLength)
+		}
+		if _, err := client.Do(context.Background(), req, nil); err != nil {
+			t.Fatalf("Do returned unexcepted error: %v", err)
+		}
+	}
+	want := "logo.png:image/png:" + string(pngHeader)
+	if len(received) != 2 || received[0] != want || received[1] != want {
+		t.Errorf("received got %q, want two %q", received, want)
+	}
+}
+
+func TestClient_NewRequest_concurrent(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	dir, err := ioutil.TempDir("", "alipay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "shot.png")
+	shot := bytes.Repeat(pngHeader, 1024)
+	if err := ioutil.WriteFile(name, shot, 0644); err != nil {
+		t.Fatal(err)
+	}
+	screenShot, err := FileFromPath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logo := bytes.Repeat(pngHeader, 512)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm returned unexcepted error: %v", err)
+			return
+		}
+		for field, want := range map[string][]byte{"app_logo": logo, "first_screen_shot": shot} {
+			f, _, err := r.FormFile(field)
+			if err != nil {
+				t.Errorf("FormFile(%v) returned unexcepted error: %v", field, err)
+				continue
+			}
+			if data, _ := ioutil.ReadAll(f); !bytes.Equal(data, want) {
+				t.Errorf("%v got %d bytes, want %d", field, len(data), len(want))
+			}
+		}
+		w.Write([]byte(`{"alipay_open_mini_version_audit_apply_response":{"code":"10000","msg":"Success"}}`))
+	})
+
+	biz := &ApplyVersionAuditBiz{
+		AppVersion:      "0.0.1",
+		VersionDesc:     "小程序版本描述",
+		AppLogo:         FileFromBytes("logo.png", logo),
+		FirstScreenShot: screenShot,
+	}
+	start := make(chan struct{})
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			<-start
+			errs <- client.Mini.ApplyVersionAudit(context.Background(), biz)
+		}()
+	}
+	close(start)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("ApplyVersionAudit returned unexcepted error: %v", err)
+		}
+	}
+}
+
+type multipartReaderBiz map[string]io.Reader
+
+func (b multipartReaderBiz) Params() map[string]string { return nil }
+
+func (b multipartReaderBiz) MultipartParams() map[string]io.Reader { return b }
+
+func TestClient_NewRequest_multipartReader(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", multipartReaderBiz{"app_logo": bytes.NewReader(pngHeader)})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	_, contentTypes := readParts(t, req, body)
+	if contentTypes["app_logo"] != "image/png" {
+		t.Errorf("part content types got %v, want app_logo image/png", contentTypes)
+	}
+}
+
+func TestClient_NewRequest_closesFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alipay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(name, bytes.Repeat(pngHeader, 1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logo, err := FileFromPath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(nil, nil, nil)
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{AppName: "小程序示例", AppLogo: logo})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	// 读取部分内容后关闭，模拟请求被取消
+	if _, err := io.ReadFull(req.Body, make([]byte, 1024)); err != nil {
+		t.Fatal(err)
+	}
+	r := req.Body.(*lazyPipe).closers[0].(*pathReader)
+	req.Body.Close()
+	if r.f != nil {
+		t.Errorf("file is still open after closing request body")
+	}
+
+	// 写入失败时也会关闭文件
+	c.MaxUploadSize = int64(len(pngHeader))
+	logo.size = 0
+	req, err = c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{AppName: "小程序示例", AppLogo: logo})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	r = req.Body.(*lazyPipe).closers[0].(*pathReader)
+	if _, err := ioutil.ReadAll(req.Body); err == nil {
+		t.Errorf("reading request body excepted *FileTooLargeError")
+	}
+	if r.f != nil {
+		t.Errorf("file is still open after write error")
+	}
+	req.Body.Close()
+}
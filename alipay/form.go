@@ -0,0 +1,208 @@
+package alipay
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	fileType          = reflect.TypeOf((*File)(nil))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// formField 按json标签解析出的表单字段
+type formField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	file      bool
+}
+
+// formFieldCache 缓存每个结构体类型的表单字段，值为[]formField
+var formFieldCache sync.Map
+
+// formFields 解析结构体的表单字段，规则与encoding/json一致：使用json标签名，
+// 跳过未导出字段和"-"，匿名结构体字段展开
+func formFields(t reflect.Type) []formField {
+	if f, ok := formFieldCache.Load(t); ok {
+		return f.([]formField)
+	}
+	var fields []formField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		ft := sf.Type
+		if sf.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, f := range formFields(ft) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, formField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+			file:      ft == fileType,
+		})
+	}
+	formFieldCache.Store(t, fields)
+	return fields
+}
+
+// hasFileField 结构体（或其指针）是否包含*File字段，包含时NewRequest使用multipart上传
+func hasFileField(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, f := range formFields(t) {
+		if f.file {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeForm 将biz结构体编码为表单字段和文件
+//
+// *File字段作为文件上传；字符串、数字、布尔值和encoding.TextMarshaler直接转为字符串；
+// 切片、map、结构体等其它类型编码为JSON字符串。值为空的字段不发送，与签名规则一致。
+func encodeForm(v interface{}) (map[string]string, map[string]io.Reader, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("alipay: cannot encode %v as form", rv.Type())
+	}
+	params := make(map[string]string)
+	files := make(map[string]io.Reader)
+	for _, f := range formFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if f.file {
+			if !fv.IsNil() {
+				files[f.name] = fv.Interface().(*File)
+			}
+			continue
+		}
+		s, err := formValue(fv)
+		if err != nil {
+			return nil, nil, fmt.Errorf("alipay: encode form field %q: %w", f.name, err)
+		}
+		if s != "" {
+			params[f.name] = s
+		}
+	}
+	return params, files, nil
+}
+
+// fieldByIndex 与reflect.Value.FieldByIndex相同，但遇到nil的匿名结构体指针时返回false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// formValue 编码表单字段的值，指针和接口解引用后按实际类型编码
+func formValue(v reflect.Value) (string, error) {
+	for {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return "", nil
+		}
+		if v.Type().Implements(textMarshalerType) {
+			b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+			return string(b), err
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v.Interface()); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// isEmptyValue 与encoding/json的omitempty规则一致
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
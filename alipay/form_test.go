@@ -0,0 +1,148 @@
+package alipay
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeForm(t *testing.T) {
+	logo := FileFromBytes("logo.png", pngHeader)
+	params, files, err := encodeForm(&ApplyVersionAuditBiz{
+		AppVersion:  "0.0.1",
+		VersionDesc: "小程序版本描述",
+		RegionType:  "LOCATION",
+		ServiceRegionInfo: []*RegionInfo{
+			{ProvinceCode: "330000", ProvinceName: "浙江省"},
+		},
+		TestAccount: "account",
+		AppLogo:     logo,
+	})
+	if err != nil {
+		t.Fatalf("encodeForm returned unexcepted error: %v", err)
+	}
+	wantParams := map[string]string{
+		"app_version":         "0.0.1",
+		"version_desc":        "小程序版本描述",
+		"region_type":         "LOCATION",
+		"service_region_info": `[{"province_code":"330000","province_name":"浙江省","city_code":"","city_name":"","area_code":"","area_name":""}]`,
+		"test_accout":         "account",
+	}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("encodeForm params got %v, want %v", params, wantParams)
+	}
+	wantFiles := map[string]io.Reader{"app_logo": logo}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("encodeForm files got %v, want %v", files, wantFiles)
+	}
+}
+
+type formInner struct {
+	Memo string `json:"memo"`
+}
+
+type formBiz struct {
+	*formInner
+	Count   int               `json:"count,omitempty"`
+	Enabled bool              `json:"enabled"`
+	Ratio   float64           `json:"ratio,omitempty"`
+	Ext     map[string]string `json:"ext,omitempty"`
+	Skipped string            `json:"-"`
+	Image   *File             `json:"image"`
+	hidden  string
+}
+
+func TestEncodeForm_types(t *testing.T) {
+	biz := &formBiz{
+		Enabled: true,
+		Ratio:   0.5,
+		Ext:     map[string]string{"k": "<v>"},
+		Skipped: "skip",
+		hidden:  "hidden",
+	}
+	params, files, err := encodeForm(biz)
+	if err != nil {
+		t.Fatalf("encodeForm returned unexcepted error: %v", err)
+	}
+	want := map[string]string{"enabled": "true", "ratio": "0.5", "ext": `{"k":"<v>"}`}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("encodeForm params got %v, want %v", params, want)
+	}
+	if len(files) != 0 {
+		t.Errorf("encodeForm files got %v, want none", files)
+	}
+
+	biz.formInner = &formInner{Memo: "memo"}
+	biz.Count = 3
+	params, _, _ = encodeForm(biz)
+	if params["memo"] != "memo" || params["count"] != "3" {
+		t.Errorf("encodeForm params got %v, want memo and count", params)
+	}
+}
+
+type formPointerBiz struct {
+	Name    *string     `json:"name,omitempty"`
+	Size    *int        `json:"size,omitempty"`
+	Online  *bool       `json:"online,omitempty"`
+	Any     interface{} `json:"any,omitempty"`
+	Missing *string     `json:"missing"`
+}
+
+func TestEncodeForm_pointers(t *testing.T) {
+	name, size, online := "小程序示例", 0, false
+	biz := &formPointerBiz{Name: &name, Size: &size, Online: &online, Any: &name}
+	params, _, err := encodeForm(biz)
+	if err != nil {
+		t.Fatalf("encodeForm returned unexcepted error: %v", err)
+	}
+	want := map[string]string{"name": "小程序示例", "size": "0", "online": "false", "any": "小程序示例"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("encodeForm params got %v, want %v", params, want)
+	}
+}
+
+func TestHasFileField(t *testing.T) {
+	tests := []struct {
+		biz  interface{}
+		want bool
+	}{
+		{&ModifyBaseInfoBiz{}, true},
+		{ApplyVersionAuditBiz{}, true},
+		{&formBiz{}, true},
+		{&UploadVersionBiz{}, false},
+		{map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		if got := hasFileField(tt.biz); got != tt.want {
+			t.Errorf("hasFileField(%T) got %v, want %v", tt.biz, got, tt.want)
+		}
+	}
+}
+
+func TestClient_NewRequest_formEncoded(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &formBiz{
+		Enabled: true,
+		Image:   FileFromBytes("1.png", pngHeader),
+	})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	names, contentTypes := readParts(t, req, body)
+	want := []string{
+		"app_id", "charset", "enabled", "format", "method", "sign", "sign_type", "timestamp", "version",
+		"image",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("parts got %v, want %v", names, want)
+	}
+	if contentTypes["image"] != "image/png" {
+		t.Errorf("part content types got %v, want image image/png", contentTypes)
+	}
+	if bytes.Contains(body, []byte("biz_content")) {
+		t.Errorf("multipart body should not contain biz_content")
+	}
+}
@@ -0,0 +1,276 @@
+package alipay
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+
+	// 支持将gif转换为png或jpeg
+	_ "image/gif"
+)
+
+// ImageSpec 图片要求
+type ImageSpec struct {
+	Formats   []string // 允许的格式，与image.Decode返回的名称一致，例如png、jpeg；为空时不限制
+	MaxBytes  int64    // 最大字节数，为0时不限制
+	Width     int      // 要求的宽度，为0时不限制
+	Height    int      // 要求的高度，为0时不限制
+	MaxWidth  int      // 最大宽度，为0时不限制
+	MaxHeight int      // 最大高度，为0时不限制
+
+	// TargetWidth和TargetHeight为建议的尺寸，ValidateImage不校验，NormalizeImage会缩放到该尺寸，
+	// 同时指定了Width或Height时以Width和Height为准
+	TargetWidth  int
+	TargetHeight int
+}
+
+// 小程序图片要求
+var (
+	// LogoSpec 小程序应用logo，格式为png或jpeg，建议像素为180*180
+	LogoSpec = ImageSpec{Formats: []string{"png", "jpeg"}, MaxBytes: 2 << 20, TargetWidth: 180, TargetHeight: 180}
+	// ScreenShotSpec 审核截图，格式为png或jpeg，不能超过4M
+	ScreenShotSpec = ImageSpec{Formats: []string{"png", "jpeg"}, MaxBytes: 4 << 20}
+	// LicensePicSpec 营业执照、门头照和特殊资质图片，格式为png或jpeg，不能超过4M
+	LicensePicSpec = ImageSpec{Formats: []string{"png", "jpeg"}, MaxBytes: 4 << 20}
+)
+
+// ImageInfo 图片的格式、尺寸和字节数
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+	Size   int64
+}
+
+func (s *ImageSpec) allows(format string) bool {
+	if len(s.Formats) == 0 {
+		return true
+	}
+	for _, f := range s.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// check 校验图片信息，错误的Biz为文件名
+func (s *ImageSpec) check(name string, info *ImageInfo) error {
+	v := &validation{biz: name}
+	if len(s.Formats) > 0 {
+		v.check(s.allows(info.Format), "format", "%v is not one of %v", info.Format, strings.Join(s.Formats, ", "))
+	}
+	if s.MaxBytes > 0 {
+		v.check(info.Size <= s.MaxBytes, "size", "%d bytes exceeds %d", info.Size, s.MaxBytes)
+	}
+	if s.Width > 0 {
+		v.check(info.Width == s.Width, "width", "%d, want %d", info.Width, s.Width)
+	}
+	if s.Height > 0 {
+		v.check(info.Height == s.Height, "height", "%d, want %d", info.Height, s.Height)
+	}
+	if s.MaxWidth > 0 {
+		v.check(info.Width <= s.MaxWidth, "width", "%d exceeds %d", info.Width, s.MaxWidth)
+	}
+	if s.MaxHeight > 0 {
+		v.check(info.Height <= s.MaxHeight, "height", "%d exceeds %d", info.Height, s.MaxHeight)
+	}
+	return v.err()
+}
+
+// ValidateImage 解析图片的格式和尺寸并按spec校验，不符合要求时返回*ValidationError
+func ValidateImage(name string, data []byte, spec ImageSpec) (*ImageInfo, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("alipay: decode image %v: %w", name, err)
+	}
+	info := &ImageInfo{Format: format, Width: config.Width, Height: config.Height, Size: int64(len(data))}
+	return info, spec.check(name, info)
+}
+
+// ImageFile 校验图片并返回可以上传的*File
+func ImageFile(name string, data []byte, spec ImageSpec) (*File, error) {
+	if _, err := ValidateImage(name, data, spec); err != nil {
+		return nil, err
+	}
+	return FileFromBytes(name, data), nil
+}
+
+// NormalizeImage 返回符合spec的图片，已经符合要求且是建议的尺寸时原样返回
+//
+// 否则裁剪、缩放到要求或建议的尺寸，并重新编码为允许的格式：原图为png且允许png时编码为png，
+// 否则编码为jpeg，超过MaxBytes时逐步降低jpeg质量。文件扩展名会随格式修改。
+func NormalizeImage(name string, data []byte, spec ImageSpec) (*File, error) {
+	info, err := ValidateImage(name, data, spec)
+	if err == nil && spec.fits(info) {
+		return FileFromBytes(name, data), nil
+	}
+	if info == nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("alipay: decode image %v: %w", name, err)
+	}
+	img = spec.resize(img)
+
+	format := "jpeg"
+	if info.Format == "png" && spec.allows("png") || !spec.allows("jpeg") {
+		format = "png"
+	}
+	if !spec.allows(format) {
+		return nil, fmt.Errorf("alipay: cannot encode image %v as one of %v", name, strings.Join(spec.Formats, ", "))
+	}
+	out, err := encodeImage(img, format, spec.MaxBytes)
+	if err == nil && format == "png" && spec.MaxBytes > 0 && int64(len(out)) > spec.MaxBytes && spec.allows("jpeg") {
+		// png无法压缩到要求的大小时改用jpeg
+		format = "jpeg"
+		out, err = encodeImage(img, format, spec.MaxBytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("alipay: encode image %v: %w", name, err)
+	}
+	ext := ".jpg"
+	if format == "png" {
+		ext = ".png"
+	}
+	name = strings.TrimSuffix(name, path.Ext(name)) + ext
+	if _, err := ValidateImage(name, out, spec); err != nil {
+		return nil, err
+	}
+	return FileFromBytes(name, out), nil
+}
+
+// jpegQualities 编码jpeg时依次尝试的质量
+var jpegQualities = []int{90, 80, 70, 60, 50}
+
+// encodeImage 编码图片，jpeg超过maxBytes时降低质量
+func encodeImage(img image.Image, format string, maxBytes int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if format == "png" {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), err
+	}
+	// jpeg不支持透明，透明部分填充白色
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Over)
+	for _, quality := range jpegQualities {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		if maxBytes <= 0 || int64(buf.Len()) <= maxBytes {
+			break
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// size 返回缩放的目标尺寸，Width和Height为0时使用TargetWidth和TargetHeight
+func (s *ImageSpec) size() (int, int) {
+	width, height := s.Width, s.Height
+	if width == 0 && height == 0 {
+		width, height = s.TargetWidth, s.TargetHeight
+	}
+	return width, height
+}
+
+// fits 图片是否已经是目标尺寸
+func (s *ImageSpec) fits(info *ImageInfo) bool {
+	width, height := s.size()
+	return (width == 0 || info.Width == width) && (height == 0 || info.Height == height)
+}
+
+// resize 按spec缩放图片：指定了宽和高时居中裁剪到相同比例后缩放，
+// 只指定一边时按比例缩放，超过MaxWidth或MaxHeight时等比缩小
+func (s *ImageSpec) resize(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	width, height := s.size()
+	switch {
+	case width > 0 && height > 0:
+		// 裁剪到目标比例
+		if w*height > h*width {
+			cw := h * width / height
+			b.Min.X += (w - cw) / 2
+			b.Max.X = b.Min.X + cw
+		} else if w*height < h*width {
+			ch := w * height / width
+			b.Min.Y += (h - ch) / 2
+			b.Max.Y = b.Min.Y + ch
+		}
+		w, h = width, height
+	case width > 0:
+		w, h = width, max1(h*width/w)
+	case height > 0:
+		w, h = max1(w*height/h), height
+	}
+	if s.MaxWidth > 0 && w > s.MaxWidth {
+		w, h = s.MaxWidth, max1(h*s.MaxWidth/w)
+	}
+	if s.MaxHeight > 0 && h > s.MaxHeight {
+		w, h = max1(w*s.MaxHeight/h), s.MaxHeight
+	}
+	if w == b.Dx() && h == b.Dy() && b == img.Bounds() {
+		return img
+	}
+	return scaleImage(img, b, w, h)
+}
+
+func max1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// scaleImage 使用区域平均将img的src区域缩放到w*h，放大时退化为最近邻
+func scaleImage(img image.Image, src image.Rectangle, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	sw, sh := src.Dx(), src.Dy()
+	for y := 0; y < h; y++ {
+		y0 := src.Min.Y + y*sh/h
+		y1 := src.Min.Y + (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0 := src.Min.X + x*sw/w
+			x1 := src.Min.X + (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					// 按透明度加权，避免透明像素的颜色渗入
+					r += uint64(c.R) * uint64(c.A)
+					g += uint64(c.G) * uint64(c.A)
+					bl += uint64(c.B) * uint64(c.A)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			var c color.NRGBA
+			if a > 0 {
+				c = color.NRGBA{
+					R: uint8(r / a >> 8),
+					G: uint8(g / a >> 8),
+					B: uint8(bl / a >> 8),
+					A: uint8(a / n >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
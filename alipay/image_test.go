@@ -0,0 +1,155 @@
+package alipay
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+func testImage(t *testing.T, format string, w, h int, noise bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if noise {
+				c.R, c.G, c.B = uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256))
+			}
+			if x < 10 {
+				c.A = 0
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readImageFile(t *testing.T, f *File) *ImageInfo {
+	t.Helper()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ValidateImage(f.Name, data, ImageSpec{})
+	if err != nil {
+		t.Fatalf("ValidateImage returned unexcepted error: %v", err)
+	}
+	return info
+}
+
+func TestValidateImage(t *testing.T) {
+	data := testImage(t, "gif", 200, 100, false)
+	info, err := ValidateImage("logo.gif", data, LogoSpec)
+	if info == nil || info.Format != "gif" || info.Width != 200 || info.Height != 100 || info.Size != int64(len(data)) {
+		t.Errorf("ValidateImage got %+v, want gif 200x100", info)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Biz != "logo.gif" {
+		t.Fatalf("ValidateImage got %v, want *ValidationError", err)
+	}
+	if verr.Field("format") == nil {
+		t.Errorf("ValidateImage missing error for format in %v", err)
+	}
+	// 建议尺寸不校验
+	if verr.Field("width") != nil || verr.Field("height") != nil {
+		t.Errorf("ValidateImage got %v, want no error for target size", err)
+	}
+	if _, err := ValidateImage("logo.png", testImage(t, "png", 200, 100, false), LogoSpec); err != nil {
+		t.Errorf("ValidateImage returned unexcepted error: %v", err)
+	}
+	if _, err := ValidateImage("x.png", data, ImageSpec{Width: 180, Height: 180}); !errors.As(err, &verr) ||
+		verr.Field("width") == nil || verr.Field("height") == nil {
+		t.Errorf("ValidateImage got %v, want width and height errors", err)
+	}
+	if _, err := ValidateImage("x.png", data, ImageSpec{MaxBytes: 10, MaxWidth: 100}); !errors.As(err, &verr) ||
+		verr.Field("size") == nil || verr.Field("width") == nil {
+		t.Errorf("ValidateImage got %v, want size and width errors", err)
+	}
+	if _, err := ValidateImage("x.png", []byte("not an image"), LogoSpec); err == nil || errors.As(err, &verr) {
+		t.Errorf("ValidateImage got %v, want decode error", err)
+	}
+
+	f, err := ImageFile("logo.png", testImage(t, "png", 180, 180, false), LogoSpec)
+	if err != nil || f.ContentType() != "image/png" {
+		t.Errorf("ImageFile got %v, %v, want png file", f, err)
+	}
+	if _, err := ImageFile("logo.gif", data, LogoSpec); err == nil {
+		t.Errorf("ImageFile excepted error")
+	}
+}
+
+func TestNormalizeImage(t *testing.T) {
+	data := testImage(t, "png", 180, 180, false)
+	f, err := NormalizeImage("logo.png", data, LogoSpec)
+	if err != nil {
+		t.Fatalf("NormalizeImage returned unexcepted error: %v", err)
+	}
+	if got, _ := ioutil.ReadAll(f); !bytes.Equal(got, data) {
+		t.Errorf("NormalizeImage changed compliant image")
+	}
+
+	tests := []struct {
+		name, format string
+		w, h         int
+		spec         ImageSpec
+		wantName     string
+		want         ImageInfo
+	}{
+		{"logo.png", "png", 400, 300, LogoSpec, "logo.png", ImageInfo{Format: "png", Width: 180, Height: 180}},
+		{"logo.jpeg", "jpeg", 100, 250, LogoSpec, "logo.jpg", ImageInfo{Format: "jpeg", Width: 180, Height: 180}},
+		{"logo.gif", "gif", 180, 180, LogoSpec, "logo.jpg", ImageInfo{Format: "jpeg", Width: 180, Height: 180}},
+		{"shot.png", "png", 600, 1200, ImageSpec{Formats: []string{"png"}, MaxHeight: 600}, "shot.png", ImageInfo{Format: "png", Width: 300, Height: 600}},
+		{"shot.png", "png", 400, 100, ImageSpec{Width: 200}, "shot.png", ImageInfo{Format: "png", Width: 200, Height: 50}},
+		{"shot.png", "png", 400, 100, ImageSpec{Width: 200, TargetWidth: 100}, "shot.png", ImageInfo{Format: "png", Width: 200, Height: 50}},
+	}
+	for _, tt := range tests {
+		f, err := NormalizeImage(tt.name, testImage(t, tt.format, tt.w, tt.h, false), tt.spec)
+		if err != nil {
+			t.Errorf("NormalizeImage(%v %dx%d) returned unexcepted error: %v", tt.name, tt.w, tt.h, err)
+			continue
+		}
+		info := readImageFile(t, f)
+		info.Size = 0
+		if f.Name != tt.wantName || *info != tt.want {
+			t.Errorf("NormalizeImage(%v %dx%d) got %v %+v, want %v %+v", tt.name, tt.w, tt.h, f.Name, info, tt.wantName, tt.want)
+		}
+	}
+}
+
+func TestNormalizeImage_maxBytes(t *testing.T) {
+	data := testImage(t, "png", 300, 300, true)
+	spec := ImageSpec{Formats: []string{"png", "jpeg"}, MaxBytes: int64(len(data)) / 2}
+	f, err := NormalizeImage("shot.png", data, spec)
+	if err != nil {
+		t.Fatalf("NormalizeImage returned unexcepted error: %v", err)
+	}
+	if info := readImageFile(t, f); info.Format != "jpeg" || info.Size > spec.MaxBytes || f.Name != "shot.jpg" {
+		t.Errorf("NormalizeImage got %v %+v, want jpeg within %d bytes", f.Name, info, spec.MaxBytes)
+	}
+
+	spec = ImageSpec{Formats: []string{"png"}, MaxBytes: 100}
+	if _, err := NormalizeImage("shot.png", data, spec); err == nil {
+		t.Errorf("NormalizeImage excepted error")
+	}
+}
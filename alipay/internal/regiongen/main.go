@@ -0,0 +1,150 @@
+// regiongen 由GB/T 2260行政区划代码表生成alipay包内置的行政区划数据
+//
+// 输入每行为"代码 名称"，代码不是6位数字的行忽略，可以直接使用民政部公布的代码表。
+// 生成时直辖市的市级区划名称改为省级名称，补全缺少的省直辖县级行政区划等市级区划，
+// 并去掉县级的市辖区。
+//
+//	go run ./internal/regiongen -in internal/regiongen/gbt2260.txt -out region_data.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// municipalities 直辖市的省级代码前缀
+var municipalities = map[string]bool{"11": true, "12": true, "31": true, "50": true}
+
+func main() {
+	in := flag.String("in", "internal/regiongen/gbt2260.txt", "GB/T 2260代码表")
+	out := flag.String("out", "region_data.go", "生成的Go文件")
+	flag.Parse()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names, err := parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := normalize(names); err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parse 读取代码表，返回代码到名称的映射
+func parse(r io.Reader) (map[string]string, error) {
+	names := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !isCode(fields[0]) {
+			continue
+		}
+		code, name := fields[0], fields[1]
+		if old, ok := names[code]; ok && old != name {
+			return nil, fmt.Errorf("regiongen: duplicate code %v: %v and %v", code, old, name)
+		}
+		names[code] = name
+	}
+	return names, scanner.Err()
+}
+
+func isCode(s string) bool {
+	return len(s) == 6 && strings.Trim(s, "0123456789") == ""
+}
+
+// parentCode 与alipay包的parentRegionCode一致
+func parentCode(code string) string {
+	switch {
+	case strings.HasSuffix(code, "0000"):
+		return ""
+	case strings.HasSuffix(code, "00"):
+		return code[:2] + "0000"
+	default:
+		return code[:4] + "00"
+	}
+}
+
+// normalize 按alipay包的约定整理代码表，并检查每个区划的上级都存在
+func normalize(names map[string]string) error {
+	for code, name := range names {
+		if name == "市辖区" && !strings.HasSuffix(code, "00") {
+			delete(names, code)
+		}
+	}
+	for code := range names {
+		if strings.HasSuffix(code, "00") {
+			continue
+		}
+		city := parentCode(code)
+		if _, ok := names[city]; ok {
+			continue
+		}
+		province, ok := names[parentCode(city)]
+		switch {
+		case !ok:
+			return fmt.Errorf("regiongen: province of %v not found", code)
+		case municipalities[code[:2]] && city[2:4] == "02":
+			names[city] = "县"
+		case strings.HasSuffix(province, "自治区"):
+			names[city] = "自治区直辖县级行政区划"
+		default:
+			names[city] = "省直辖县级行政区划"
+		}
+	}
+	for code, name := range names {
+		if municipalities[code[:2]] && strings.HasSuffix(code, "0000") {
+			names[code[:2]+"0100"] = name
+		}
+	}
+	for code := range names {
+		if p := parentCode(code); p != "" {
+			if _, ok := names[p]; !ok {
+				return fmt.Errorf("regiongen: parent of %v not found", code)
+			}
+		}
+	}
+	return nil
+}
+
+// generate 生成按代码排序的region_data.go
+func generate(names map[string]string) ([]byte, error) {
+	codes := make([]string, 0, len(names))
+	for code := range names {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by regiongen; DO NOT EDIT.\n\n")
+	buf.WriteString("package alipay\n\n")
+	buf.WriteString("// regionData 内置的行政区划数据，每行为\"代码 名称\"，来源为GB/T 2260行政区划代码\n")
+	buf.WriteString("//\n")
+	buf.WriteString("// 包含全部省级、地级和县级行政区划，由internal/regiongen/gbt2260.txt生成。\n")
+	buf.WriteString("// 直辖市的市级区划名称与省级相同，例如110100 北京市。\n")
+	buf.WriteString("const regionData = `\n")
+	for _, code := range codes {
+		fmt.Fprintf(&buf, "%v %v\n", code, names[code])
+	}
+	buf.WriteString("`\n")
+	return format.Source(buf.Bytes())
+}
@@ -52,47 +52,20 @@ type ModifyBaseInfoBiz struct {
 	MiniCategoryIDs string `json:"mini_category_ids,omitempty"` // 新小程序前台类目，一级与二级、三级用下划线隔开，最多可以选四个类目，类目之间;隔开。使用后不再读取app_category_ids值，老前台类目将废弃
 }
 
+// Validate 校验参数
+func (b *ModifyBaseInfoBiz) Validate() error {
+	v := newValidation(b)
+	v.length("app_desc", b.AppDesc, 20, 200)
+	v.categories("mini_category_ids", b.MiniCategoryIDs, maxCategories)
+	return v.err()
+}
+
+// MultiRender 自定义multipart表单字段和文件，未实现时按json标签编码包含*File字段的结构体
 type MultiRender interface {
 	Params() map[string]string
 	MultipartParams() map[string]io.Reader
 }
 
-func (b *ModifyBaseInfoBiz) Params() map[string]string {
-	params := make(map[string]string)
-	if b.AppName != "" {
-		params["app_name"] = b.AppName
-	}
-	if b.AppEnglishName != "" {
-		params["app_english_name"] = b.AppEnglishName
-	}
-	if b.AppSlogan != "" {
-		params["app_slogan"] = b.AppSlogan
-	}
-	if b.AppCategoryIDs != "" {
-		params["app_category_ids"] = b.AppCategoryIDs
-	}
-	if b.AppDesc != "" {
-		params["app_desc"] = b.AppDesc
-	}
-	if b.ServicePhone != "" {
-		params["service_phone"] = b.ServicePhone
-	}
-	if b.ServiceEmail != "" {
-		params["service_email"] = b.ServiceEmail
-	}
-	if b.MiniCategoryIDs != "" {
-		params["mini_category_ids"] = b.MiniCategoryIDs
-	}
-	return params
-}
-func (b *ModifyBaseInfoBiz) MultipartParams() map[string]io.Reader {
-	params := make(map[string]io.Reader)
-	if b.AppLogo != nil {
-		params["app_logo"] = b.AppLogo
-	}
-	return params
-}
-
 // ModifyBaseInfo 小程序修改基础信息
 func (s *MiniService) ModifyBaseInfo(ctx context.Context, biz *ModifyBaseInfoBiz, opts ...ValueOptions) error {
 	apiMethod := "alipay.open.mini.baseinfo.modify"
@@ -112,6 +85,13 @@ type CreateSafeDomainBiz struct {
 	SafeDomain string `json:"safe_domain"` // httpRequest域白名单 示例值：example.com 一次只支持设置一个域名
 }
 
+// Validate 校验参数
+func (b *CreateSafeDomainBiz) Validate() error {
+	v := newValidation(b)
+	v.required("safe_domain", b.SafeDomain)
+	return v.err()
+}
+
 // CreateSafeDomain 小程序添加域白名单
 func (s *MiniService) CreateSafeDomain(ctx context.Context, biz *CreateSafeDomainBiz, opts ...ValueOptions) error {
 	apiMethod := "alipay.open.mini.safedomain.create"
@@ -132,6 +112,14 @@ type DetectRiskContentBiz struct {
 
 }
 
+// Validate 校验参数
+func (b *DetectRiskContentBiz) Validate() error {
+	v := newValidation(b)
+	v.required("content", b.Content)
+	v.maxLength("content", b.Content, 2000)
+	return v.err()
+}
+
 // DetectRiskContentResp 小程序风险内容检测服务resp
 type DetectRiskContentResp struct {
 	Action   string   `json:"action"`    //表示处理结果，REJECTED表示拦截，PASSED表示放过。
@@ -160,6 +148,13 @@ type QueryTinyAppExistBiz struct {
 	PID string `json:"pid"` //支付宝账号ID
 }
 
+// Validate 校验参数
+func (b *QueryTinyAppExistBiz) Validate() error {
+	v := newValidation(b)
+	v.required("pid", b.PID)
+	return v.err()
+}
+
 // QueryTinyAppExistResp 查询是否创建过小程序resp
 type QueryTinyAppExistResp struct {
 	ExistMini string `json:"exist_mini"` // 是否是小程序开发者
@@ -225,6 +220,14 @@ type CertifyIndividualBusinessBiz struct {
 	LicesePic string `json:"license_pic"` //	营业执照图片的Base64编码字符串，图片大小不能超过2M
 }
 
+// Validate 校验参数
+func (b *CertifyIndividualBusinessBiz) Validate() error {
+	v := newValidation(b)
+	v.required("license_no", b.LiceseNo)
+	v.required("license_pic", b.LicesePic)
+	return v.err()
+}
+
 // CertifyIndividualBusinessResp 个人账户升级为个体工商户resp
 type CertifyIndividualBusinessResp struct {
 	CertifyResult bool `json:"certify_result"` // 个体工商户认证结果，true代表认证成功，false代表认证失败
@@ -254,6 +257,15 @@ type SyncContentBiz struct {
 	ExtendInfo  string `json:"extend_info"`  // 扩展信息，json格式。可参考具体内容接入文档中的详细说明。
 }
 
+// Validate 校验参数
+func (b *SyncContentBiz) Validate() error {
+	v := newValidation(b)
+	v.required("content_type", b.ContentType)
+	v.required("operation", b.Operation)
+	v.required("content_data", b.ContentData)
+	return v.err()
+}
+
 // SyncContentResp 小程序内容接入resp
 type SyncContentResp struct {
 	ResultData string `json:"result_data"` // 结果数据，json格式，可参考具体内容接入文档中的详细说明。
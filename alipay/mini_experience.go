@@ -1,6 +1,9 @@
 package alipay
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // CreateExperienceBiz 小程序生成体验版
 type CreateExperienceBiz struct {
@@ -11,7 +14,12 @@ type CreateExperienceBiz struct {
 	// 高德端:com.amap.app,
 	// 天猫精灵端:com.alibaba.ailabs.genie.webapps,
 	// 支付宝IOT:com.alipay.iot.xpaas
-	BundleID string `json:"bundle_id"`
+	BundleID BundleID `json:"bundle_id"`
+}
+
+// Validate 校验参数
+func (b *CreateExperienceBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // CreateExperience 小程序生成体验版
@@ -37,13 +45,18 @@ type QueryExperienceBiz struct {
 	// 高德端:com.amap.app,
 	// 天猫精灵端:com.alibaba.ailabs.genie.webapps,
 	// 支付宝IOT:com.alipay.iot.xpaas
-	BundleID string `json:"bundle_id"`
+	BundleID BundleID `json:"bundle_id"`
+}
+
+// Validate 校验参数
+func (b *QueryExperienceBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // ExperienceStatus 体验版状态
 type ExperienceStatus struct {
-	Status       string `json:"status,omitempty"`          // 体验版打包状态，expVersionPackaged-体验版打包成功，expVersionPackaging-体验版打包中，notExpVersion-非体验版
-	ExpQrCodeURL string `json:"exp_qr_code_url,omitempty"` // 小程序体验版二维码地址
+	Status       ExperiencePackageStatus `json:"status,omitempty"`          // 体验版打包状态，expVersionPackaged-体验版打包成功，expVersionPackaging-体验版打包中，notExpVersion-非体验版
+	ExpQrCodeURL string                  `json:"exp_qr_code_url,omitempty"` // 小程序体验版二维码地址
 }
 
 // QueryExperience 小程序体验版状态查询
@@ -70,7 +83,12 @@ type CancelExperienceBiz struct {
 	// 高德端:com.amap.app,
 	// 天猫精灵端:com.alibaba.ailabs.genie.webapps,
 	// 支付宝IOT:com.alipay.iot.xpaas
-	BundleID string `json:"bundle_id"`
+	BundleID BundleID `json:"bundle_id"`
+}
+
+// Validate 校验参数
+func (b *CancelExperienceBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // CancelExperience 小程序取消体验版
@@ -86,3 +104,45 @@ func (s *MiniService) CancelExperience(ctx context.Context, biz *CancelExperienc
 	}
 	return nil
 }
+
+// ExperienceError 创建体验版后查询到非体验版
+type ExperienceError struct {
+	AppVersion string
+	Status     ExperiencePackageStatus
+}
+
+func (e *ExperienceError) Error() string {
+	return fmt.Sprintf("alipay: experience of version %v failed: %v (status %v)", e.AppVersion, e.Status, string(e.Status))
+}
+
+// CreateExperienceAndWait 生成体验版，并按Client.PollBackoff轮询直到打包完成，见CreateExperienceAndWait函数
+func (s *MiniService) CreateExperienceAndWait(ctx context.Context, biz *CreateExperienceBiz, opts ...ValueOptions) (*ExperienceStatus, error) {
+	return CreateExperienceAndWait(ctx, s, biz, opts...)
+}
+
+// CreateExperienceAndWait 通过mini生成体验版，并轮询直到打包完成，返回带二维码地址的体验版状态
+//
+// mini实现了Poller时按PollBackoff轮询，否则使用DefaultBackoff。
+// 打包结束后状态不是expVersionPackaged（例如notExpVersion）时返回*ExperienceError，ctx结束时返回ctx.Err()。
+func CreateExperienceAndWait(ctx context.Context, mini MiniAPI, biz *CreateExperienceBiz, opts ...ValueOptions) (*ExperienceStatus, error) {
+	if err := mini.CreateExperience(ctx, biz, opts...); err != nil {
+		return nil, err
+	}
+	query := &QueryExperienceBiz{AppVersion: biz.AppVersion, BundleID: biz.BundleID}
+	var status *ExperienceStatus
+	err := poll(ctx, pollBackoff(mini), func() (bool, error) {
+		var err error
+		status, err = mini.QueryExperience(ctx, query, opts...)
+		if err != nil {
+			return false, err
+		}
+		return status.Status != ExpVersionPackaging, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != ExpVersionPackaged {
+		return status, &ExperienceError{AppVersion: biz.AppVersion, Status: status.Status}
+	}
+	return status, nil
+}
@@ -2,10 +2,12 @@ package alipay
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestMiniService_CancelExperience(t *testing.T) {
@@ -164,3 +166,84 @@ func TestMiniService_QueryExperience_failed(t *testing.T) {
 		t.Errorf("Mini.QueryExperience excepted error")
 	}
 }
+
+func TestMiniService_CreateExperienceAndWait(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Millisecond}
+
+	var queries int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("method") {
+		case "alipay.open.mini.experience.create":
+			fmt.Fprint(w, `{"alipay_open_mini_experience_create_response":{"code":"10000","msg":"Success"}}`)
+		case "alipay.open.mini.experience.query":
+			queries++
+			status := "expVersionPackaging"
+			if queries == 3 {
+				status = "expVersionPackaged"
+			}
+			fmt.Fprintf(w, `{
+							"alipay_open_mini_experience_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"status": "%v",
+								"exp_qr_code_url": "http://mmtcdp.stable.alipay.net/wsdk/img?fileid=A*lSbPT5i9C1wAAAAAAAAAAABjAQAAAA&t=9005d7f574f00e1a9bb6ee3c1bb5abd5&bz=mmtcafts&"
+							}
+						}`, status)
+		default:
+			t.Errorf("unexcepted method %v", r.FormValue("method"))
+		}
+	})
+
+	got, err := client.Mini.CreateExperienceAndWait(context.Background(), &CreateExperienceBiz{
+		AppVersion: "0.0.1",
+		BundleID:   BundleIDAlipay,
+	})
+	if err != nil {
+		t.Fatalf("Mini.CreateExperienceAndWait returned unexcepted error: %v", err)
+	}
+	if got.Status != ExpVersionPackaged || got.ExpQrCodeURL == "" || queries != 3 {
+		t.Errorf("Mini.CreateExperienceAndWait got %+v after %d queries", got, queries)
+	}
+}
+
+func TestMiniService_CreateExperienceAndWait_notExpVersion(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Millisecond}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("method") == "alipay.open.mini.experience.create" {
+			fmt.Fprint(w, `{"alipay_open_mini_experience_create_response":{"code":"10000","msg":"Success"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"alipay_open_mini_experience_query_response":{"code":"10000","msg":"Success","status":"notExpVersion"}}`)
+	})
+
+	_, err := client.Mini.CreateExperienceAndWait(context.Background(), &CreateExperienceBiz{AppVersion: "0.0.1"})
+	var expErr *ExperienceError
+	if !errors.As(err, &expErr) || expErr.Status != NotExpVersion {
+		t.Errorf("Mini.CreateExperienceAndWait got %v, want *ExperienceError", err)
+	}
+}
+
+func TestMiniService_CreateExperienceAndWait_canceled(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Hour}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("method") == "alipay.open.mini.experience.create" {
+			fmt.Fprint(w, `{"alipay_open_mini_experience_create_response":{"code":"10000","msg":"Success"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"alipay_open_mini_experience_query_response":{"code":"10000","msg":"Success","status":"expVersionPackaging"}}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Mini.CreateExperienceAndWait(ctx, &CreateExperienceBiz{AppVersion: "0.0.1"}); err != context.DeadlineExceeded {
+		t.Errorf("Mini.CreateExperienceAndWait got %v, want context.DeadlineExceeded", err)
+	}
+}
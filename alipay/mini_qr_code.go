@@ -12,6 +12,17 @@ type BindQrCodeBiz struct {
 	PageRedirection string `json:"page_redirection"` // 小程序功能页，配置扫描二维码后打开的小程序功能页面路径
 }
 
+// Validate 校验参数
+func (b *BindQrCodeBiz) Validate() error {
+	v := newValidation(b)
+	v.required("route_url", b.RouteURL)
+	if v.required("mode", b.Mode) {
+		v.oneOf("mode", b.Mode, "EXACT", "FUZZY")
+	}
+	v.required("page_redirection", b.PageRedirection)
+	return v.err()
+}
+
 // BindQrCodeResp 关联普通二维码resp
 type BindQrCodeResp struct {
 	RouteGroup string `json:"route_group"` // 路由规则组，用于唯一标记一条路由规则
@@ -37,6 +48,13 @@ type UnbindQrCodeBiz struct {
 	RouteGroup string `json:"route_group"` // 路由规则组，用于唯一标记一条路由规则
 }
 
+// Validate 校验参数
+func (b *UnbindQrCodeBiz) Validate() error {
+	v := newValidation(b)
+	v.required("route_group", b.RouteGroup)
+	return v.err()
+}
+
 // UnbindQrCode 删除已关联普通二维码
 func (s *MiniService) UnbindQrCode(ctx context.Context, biz *UnbindQrCodeBiz, opts ...ValueOptions) error {
 	apiMethod := "alipay.open.mini.qrcode.unbind"
@@ -14,7 +14,17 @@ type QueryTemplateUsageBiz struct {
 	// 高德端:com.amap.app,
 	// 天猫精灵端:com.alibaba.ailabs.genie.webapps,
 	// 支付宝IOT:com.alipay.iot.xpaas
-	BundleID string `json:"bundle_id,omitempty"`
+	BundleID BundleID `json:"bundle_id,omitempty"`
+}
+
+// Validate 校验参数
+func (b *QueryTemplateUsageBiz) Validate() error {
+	v := newValidation(b)
+	v.required("template_id", b.TemplateID)
+	v.check(b.PageNum >= 0, "page_num", "must not be negative")
+	v.check(b.PageSize >= 0 && b.PageSize <= 50, "page_size", "%d out of range [0, 50]", b.PageSize)
+	v.version("template_version", b.TemplateVersion)
+	return v.err()
 }
 
 // QueryTemplateUsageResp 查询使用模板的小程序列表resp
@@ -42,3 +52,103 @@ func (s *MiniService) QueryTemplateUsage(ctx context.Context, biz *QueryTemplate
 	}
 	return resp, nil
 }
+
+// maxTemplateUsagePageSize QueryTemplateUsage每页最多查询的数量
+const maxTemplateUsagePageSize = 50
+
+// TemplateUsageQuerier 可以查询模板使用情况的服务，*MiniService和MiniAPI都满足该接口
+type TemplateUsageQuerier interface {
+	QueryTemplateUsage(ctx context.Context, biz *QueryTemplateUsageBiz, opts ...ValueOptions) (*QueryTemplateUsageResp, error)
+}
+
+// TemplateUsageIterator 逐个遍历使用模板的小程序，自动翻页
+//
+// 接口不返回总数，返回数量少于PageSize的一页时认为已经到达最后一页。
+//
+//	it := client.Mini.TemplateUsages(&alipay.QueryTemplateUsageBiz{TemplateID: "1"})
+//	for it.Next(ctx) {
+//		usage := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type TemplateUsageIterator struct {
+	querier TemplateUsageQuerier
+	biz     QueryTemplateUsageBiz
+	opts    []ValueOptions
+
+	page  []*TemplateUsageInfo
+	value *TemplateUsageInfo
+	last  bool
+	err   error
+}
+
+// NewTemplateUsageIterator 创建遍历biz对应的模板使用情况的迭代器
+//
+// biz为nil时按空的QueryTemplateUsageBiz处理，biz.PageNum为0时从第一页开始，biz.PageSize为0时每页查询最大数量50个。
+func NewTemplateUsageIterator(querier TemplateUsageQuerier, biz *QueryTemplateUsageBiz, opts ...ValueOptions) *TemplateUsageIterator {
+	if biz == nil {
+		biz = &QueryTemplateUsageBiz{}
+	}
+	it := &TemplateUsageIterator{querier: querier, biz: *biz, opts: opts}
+	if it.biz.PageNum <= 0 {
+		it.biz.PageNum = 1
+	}
+	if it.biz.PageSize <= 0 {
+		it.biz.PageSize = maxTemplateUsagePageSize
+	}
+	return it
+}
+
+// Next 前进到下一个小程序，没有更多小程序、查询失败或ctx结束时返回false
+func (it *TemplateUsageIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for len(it.page) == 0 {
+		if it.last {
+			it.value = nil
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		biz := it.biz
+		resp, err := it.querier.QueryTemplateUsage(ctx, &biz, it.opts...)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = resp.TemplateUsageInfoList
+		it.last = len(it.page) < it.biz.PageSize
+		it.biz.PageNum++
+	}
+	it.value, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Value 返回当前的小程序，需要在Next返回true之后调用
+func (it *TemplateUsageIterator) Value() *TemplateUsageInfo {
+	return it.value
+}
+
+// Err 返回导致Next停止的错误，正常遍历结束时返回nil
+func (it *TemplateUsageIterator) Err() error {
+	return it.err
+}
+
+// All 遍历剩余的全部小程序
+func (it *TemplateUsageIterator) All(ctx context.Context) ([]*TemplateUsageInfo, error) {
+	var usages []*TemplateUsageInfo
+	for it.Next(ctx) {
+		usages = append(usages, it.Value())
+	}
+	return usages, it.Err()
+}
+
+// TemplateUsages 返回遍历使用模板的全部小程序的迭代器，见NewTemplateUsageIterator
+func (s *MiniService) TemplateUsages(biz *QueryTemplateUsageBiz, opts ...ValueOptions) *TemplateUsageIterator {
+	return NewTemplateUsageIterator(s, biz, opts...)
+}
@@ -2,9 +2,11 @@ package alipay
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +76,93 @@ func TestMiniService_QueryTemplateUsage_failed(t *testing.T) {
 		t.Errorf("Mini.QueryTemplateUsage excepted error")
 	}
 }
+
+func TestTemplateUsageIterator(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	var pages []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var biz QueryTemplateUsageBiz
+		if err := json.Unmarshal([]byte(r.FormValue("biz_content")), &biz); err != nil {
+			t.Fatalf("biz_content returned unexcepted error: %v", err)
+		}
+		pages = append(pages, fmt.Sprintf("%d/%d", biz.PageNum, biz.PageSize))
+		var list []string
+		for i := (biz.PageNum - 1) * biz.PageSize; i < 5 && i < biz.PageNum*biz.PageSize; i++ {
+			list = append(list, fmt.Sprintf(`{"mini_app_id": "%d", "app_version": "0.0.1"}`, i))
+		}
+		fmt.Fprintf(w, `{
+							"alipay_open_mini_template_usage_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"template_usage_info_list": [%v]
+							}
+						}`, strings.Join(list, ","))
+	})
+
+	it := client.Mini.TemplateUsages(&QueryTemplateUsageBiz{TemplateID: "1", PageSize: 2})
+	var got []string
+	for it.Next(context.Background()) {
+		got = append(got, it.Value().MiniAppID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("TemplateUsageIterator returned unexcepted error: %v", err)
+	}
+	if want := []string{"0", "1", "2", "3", "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TemplateUsageIterator got %v, want %v", got, want)
+	}
+	if want := []string{"1/2", "2/2", "3/2"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages got %v, want %v", pages, want)
+	}
+	if it.Next(context.Background()) || it.Value() != nil {
+		t.Errorf("TemplateUsageIterator.Next got true after last page")
+	}
+
+	pages = nil
+	all, err := client.Mini.TemplateUsages(&QueryTemplateUsageBiz{TemplateID: "1", PageSize: 5}).All(context.Background())
+	if err != nil || len(all) != 5 {
+		t.Errorf("TemplateUsageIterator.All got %v, %v, want 5 usages", len(all), err)
+	}
+	if want := []string{"1/5", "2/5"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages got %v, want %v", pages, want)
+	}
+
+	pages = nil
+	all, _ = client.Mini.TemplateUsages(&QueryTemplateUsageBiz{TemplateID: "1"}).All(context.Background())
+	if len(all) != 5 || !reflect.DeepEqual(pages, []string{"1/50"}) {
+		t.Errorf("TemplateUsageIterator.All got %v usages from pages %v, want 5 from [1/50]", len(all), pages)
+	}
+}
+
+func TestTemplateUsageIterator_failed(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_template_usage_query_response": {
+								"code": "20000",
+								"msg": "Service Currently Unavailable",
+								"sub_code": "isp.unknow-error",
+								"sub_msg": "系统繁忙"
+							}
+						}`)
+	})
+
+	if _, err := client.Mini.TemplateUsages(&QueryTemplateUsageBiz{TemplateID: "1"}).All(context.Background()); err == nil {
+		t.Errorf("TemplateUsageIterator.All excepted error")
+	}
+	// biz为nil时不会panic，缺少template_id校验失败
+	_, err := NewTemplateUsageIterator(client.Mini, nil).All(context.Background())
+	if e, ok := err.(*ValidationError); !ok || e.Field("template_id") == nil {
+		t.Errorf("TemplateUsageIterator.All got error %v, want template_id ValidationError", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it := client.Mini.TemplateUsages(&QueryTemplateUsageBiz{TemplateID: "1"})
+	if it.Next(ctx) || it.Err() != context.Canceled {
+		t.Errorf("TemplateUsageIterator.Err got %v, want context.Canceled", it.Err())
+	}
+}
@@ -2,8 +2,8 @@ package alipay
 
 import (
 	"context"
-	"encoding/json"
-	"io"
+	"fmt"
+	"time"
 )
 
 // QueryVersionListResp 查询小程序列表返回值
@@ -28,8 +28,13 @@ func (s *MiniService) QueryVersionList(ctx context.Context, opts ...ValueOptions
 
 // DeleteVersionBiz 小程序删除版本
 type DeleteVersionBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号
-	BundleID   string `json:"bundle_id"`   //小程序投放的端参数，例如投放到支付宝钱包是支付宝端。该参数可选，默认支付宝端，目前仅支持支付宝端，枚举列举：com.alipay.alipaywallet:支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号
+	BundleID   BundleID `json:"bundle_id"`   //小程序投放的端参数，例如投放到支付宝钱包是支付宝端。该参数可选，默认支付宝端，目前仅支持支付宝端，枚举列举：com.alipay.alipaywallet:支付宝端
+}
+
+// Validate 校验参数
+func (b *DeleteVersionBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // DeleteVersion 小程序删除版本
@@ -82,123 +87,23 @@ type ApplyVersionAuditBiz struct {
 	TestAccount             string        `json:"test_accout,omitempty"` // 官方拼写错误
 	TestPassword            string        `json:"test_password,omitempty"`
 	TestFileName            *File         `json:"test_file_name,omitempty"`
-	BundleID                string        `json:"bundle_id,omitempty"`
+	BundleID                BundleID      `json:"bundle_id,omitempty"`
 }
 
-func (a ApplyVersionAuditBiz) Params() map[string]string {
-	params := make(map[string]string)
-	if a.LicenseName != "" {
-		params["license_name"] = a.LicenseName
-	}
-	if a.LicenseValidDate != "" {
-		params["license_valid_date"] = a.LicenseValidDate
-	}
-	if a.AppVersion != "" {
-		params["app_version"] = a.AppVersion
-	}
-	if a.AppName != "" {
-		params["app_name"] = a.AppName
-	}
-	if a.AppEnglishName != "" {
-		params["app_english_name"] = a.AppEnglishName
-	}
-	if a.AppSlogan != "" {
-		params["app_slogan"] = a.AppSlogan
-	}
-	if a.AppCategoryIDs != "" {
-		params["app_category_ids"] = a.AppCategoryIDs
-	}
-	if a.AppDesc != "" {
-		params["app_desc"] = a.AppDesc
-	}
-	if a.ServicePhone != "" {
-		params["service_phone"] = a.ServicePhone
+// Validate 校验参数
+func (b *ApplyVersionAuditBiz) Validate() error {
+	v := newValidation(b)
+	if v.required("app_version", b.AppVersion) {
+		v.version("app_version", b.AppVersion)
 	}
-	if a.ServiceEmail != "" {
-		params["service_email"] = a.ServiceEmail
+	v.required("version_desc", b.VersionDesc)
+	v.length("app_desc", b.AppDesc, 20, 200)
+	v.categories("mini_category_ids", b.MiniCategoryIDs, maxCategories)
+	v.oneOf("region_type", b.RegionType, "GLOBAL", "CHINA", "LOCATION")
+	if b.RegionType == "LOCATION" {
+		v.check(len(b.ServiceRegionInfo) > 0, "service_region_info", "is required when region_type is LOCATION")
 	}
-	if a.VersionDesc != "" {
-		params["version_desc"] = a.VersionDesc
-	}
-	if a.Memo != "" {
-		params["memo"] = a.Memo
-	}
-	if a.RegionType != "" {
-		params["region_type"] = a.RegionType
-	}
-	if a.ServiceRegionInfo != nil {
-		serviceRegionInfo, _ := json.Marshal(a.ServiceRegionInfo)
-		params["service_region_info"] = string(serviceRegionInfo)
-	}
-	if a.LicenseNo != "" {
-		params["license_no"] = a.LicenseNo
-	}
-	if a.MiniCategoryIDs != "" {
-		params["mini_category_ids"] = a.MiniCategoryIDs
-	}
-	if a.TestAccount != "" {
-		params["test_accout"] = a.TestAccount
-	}
-	if a.TestPassword != "" {
-		params["test_password"] = a.TestPassword
-	}
-	if a.BundleID != "" {
-		params["bundle_id"] = a.BundleID
-	}
-	return params
-}
-
-func (a ApplyVersionAuditBiz) MultipartParams() map[string]io.Reader {
-	params := make(map[string]io.Reader)
-	if a.FirstLicensePic != nil {
-		params["first_license_pic"] = a.FirstLicensePic
-	}
-	if a.SecondLicensePic != nil {
-		params["second_license_pic"] = a.SecondLicensePic
-	}
-	if a.ThirdLicensePic != nil {
-		params["third_license_pic"] = a.ThirdLicensePic
-	}
-	if a.FourthLicensePic != nil {
-		params["fourth_license_pic"] = a.FourthLicensePic
-	}
-	if a.FifthLicensePic != nil {
-		params["fifth_license_pic"] = a.FifthLicensePic
-	}
-	if a.OutDoorPic != nil {
-		params["out_door_pic"] = a.OutDoorPic
-	}
-	if a.AppLogo != nil {
-		params["app_logo"] = a.AppLogo
-	}
-	if a.FirstScreenShot != nil {
-		params["first_screen_shot"] = a.FirstScreenShot
-	}
-	if a.SecondScreenShot != nil {
-		params["second_screen_shot"] = a.SecondScreenShot
-	}
-	if a.ThirdScreenShot != nil {
-		params["third_screen_shot"] = a.ThirdScreenShot
-	}
-	if a.FourthScreenShot != nil {
-		params["fourth_screen_shot"] = a.FourthScreenShot
-	}
-	if a.FifthScreenShot != nil {
-		params["fifth_screen_shot"] = a.FifthScreenShot
-	}
-	if a.FirstSpecialLicensePic != nil {
-		params["first_special_license_pic"] = a.FirstSpecialLicensePic
-	}
-	if a.SecondSpecialLicensePic != nil {
-		params["second_special_license_pic"] = a.SecondSpecialLicensePic
-	}
-	if a.ThirdSpecialLicensePic != nil {
-		params["third_special_license_pic"] = a.ThirdSpecialLicensePic
-	}
-	if a.TestFileName != nil {
-		params["test_file_name"] = a.TestFileName
-	}
-	return params
+	return v.err()
 }
 
 // RegionInfo 省市区信息，当区域类型为LOCATION时，不能为空
@@ -227,8 +132,15 @@ func (s *MiniService) ApplyVersionAudit(ctx context.Context, biz *ApplyVersionAu
 
 // CancelVersionAuditBiz 小程序撤销审核
 type CancelVersionAuditBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 可不选, 默认撤消正在审核中的版本
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端(com.alipay.alipaywallet:支付宝端)
+	AppVersion string   `json:"app_version"` //小程序版本号, 可不选, 默认撤消正在审核中的版本
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端(com.alipay.alipaywallet:支付宝端)
+}
+
+// Validate 校验参数
+func (b *CancelVersionAuditBiz) Validate() error {
+	v := newValidation(b)
+	v.version("app_version", b.AppVersion)
+	return v.err()
 }
 
 // CancelVersionAudit 小程序撤销审核
@@ -247,8 +159,13 @@ func (s *MiniService) CancelVersionAudit(ctx context.Context, biz *CancelVersion
 
 // CancelVersionAuditedBiz 小程序退回开发
 type CancelVersionAuditedBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号
-	BundleID   string `json:"bundle_id"`   //小程序投放的端参数，例如投放到支付宝钱包是支付宝端。该参数可选，默认支付宝端，目前仅支持支付宝端，枚举列举：com.alipay.alipaywallet:支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号
+	BundleID   BundleID `json:"bundle_id"`   //小程序投放的端参数，例如投放到支付宝钱包是支付宝端。该参数可选，默认支付宝端，目前仅支持支付宝端，枚举列举：com.alipay.alipaywallet:支付宝端
+}
+
+// Validate 校验参数
+func (b *CancelVersionAuditedBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // CancelVersionAudited 小程序退回开发
@@ -267,8 +184,13 @@ func (s *MiniService) CancelVersionAudited(ctx context.Context, biz *CancelVersi
 
 // OnlineVersionBiz 小程序上架
 type OnlineVersionBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *OnlineVersionBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // OnlineVersion 小程序上架
@@ -287,8 +209,13 @@ func (s *MiniService) OnlineVersion(ctx context.Context, biz *OnlineVersionBiz,
 
 // OfflineVersionBiz 小程序下架
 type OfflineVersionBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *OfflineVersionBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // OfflineVersion 小程序下架
@@ -307,8 +234,13 @@ func (s *MiniService) OfflineVersion(ctx context.Context, biz *OfflineVersionBiz
 
 // RollbackVersionBiz 小程序回滚
 type RollbackVersionBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *RollbackVersionBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // RollbackVersion 小程序回滚
@@ -327,9 +259,21 @@ func (s *MiniService) RollbackVersion(ctx context.Context, biz *RollbackVersionB
 
 // OnlineGrayVersionBiz 小程序灰度上架
 type OnlineGrayVersionBiz struct {
-	AppVersion   string `json:"app_version"`   //小程序版本号, 必选
-	GrayStrategy string `json:"gray_strategy"` //小程序灰度策略值，支持p10，p30，p50, 代表百分之多少的用户
-	BundleID     string `json:"bundle_id"`     //端参数，可不选，默认支付宝端
+	AppVersion   string       `json:"app_version"`   //小程序版本号, 必选
+	GrayStrategy GrayStrategy `json:"gray_strategy"` //小程序灰度策略值，支持p10，p30，p50, 代表百分之多少的用户
+	BundleID     BundleID     `json:"bundle_id"`     //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *OnlineGrayVersionBiz) Validate() error {
+	v := newValidation(b)
+	if v.required("app_version", b.AppVersion) {
+		v.version("app_version", b.AppVersion)
+	}
+	if v.required("gray_strategy", string(b.GrayStrategy)) {
+		v.oneOf("gray_strategy", string(b.GrayStrategy), string(GrayStrategyP10), string(GrayStrategyP30), string(GrayStrategyP50))
+	}
+	return v.err()
 }
 
 // OnlineGrayVersion 小程序灰度上架
@@ -348,11 +292,16 @@ func (s *MiniService) OnlineGrayVersion(ctx context.Context, biz *OnlineGrayVers
 
 // CancelGrayVersionBiz 小程序结束灰度
 type CancelGrayVersionBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
 }
 
-// CancelGrayVersion 小程序灰度上架
+// Validate 校验参数
+func (b *CancelGrayVersionBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
+}
+
+// CancelGrayVersion 小程序结束灰度
 func (s *MiniService) CancelGrayVersion(ctx context.Context, biz *CancelGrayVersionBiz, opts ...ValueOptions) error {
 	apiMethod := "alipay.open.mini.version.gray.cancel"
 	req, err := s.client.NewRequest(apiMethod, biz, opts...)
@@ -368,11 +317,23 @@ func (s *MiniService) CancelGrayVersion(ctx context.Context, biz *CancelGrayVers
 
 // UploadVersionBiz 小程序基于模板上传版本
 type UploadVersionBiz struct {
-	AppVersion      string `json:"app_version"`      //小程序版本号, 必选
-	BundleID        string `json:"bundle_id"`        //端参数，可不选，默认支付宝端
-	TemplateID      string `json:"template_id"`      //模板id
-	Ext             string `json:"ext"`              //模板的配置参数
-	TemplateVersion string `json:"template_version"` //模板版本号，版本号必须满足 x.y.z, 且均为数字。不传默认使用最新在架模板版本。
+	AppVersion      string   `json:"app_version"`      //小程序版本号, 必选
+	BundleID        BundleID `json:"bundle_id"`        //端参数，可不选，默认支付宝端
+	TemplateID      string   `json:"template_id"`      //模板id
+	Ext             string   `json:"ext"`              //模板的配置参数
+	TemplateVersion string   `json:"template_version"` //模板版本号，版本号必须满足 x.y.z, 且均为数字。不传默认使用最新在架模板版本。
+}
+
+// Validate 校验参数
+func (b *UploadVersionBiz) Validate() error {
+	v := newValidation(b)
+	if v.required("app_version", b.AppVersion) {
+		v.version("app_version", b.AppVersion)
+	}
+	v.required("template_id", b.TemplateID)
+	v.ext("ext", b.Ext)
+	v.version("template_version", b.TemplateVersion)
+	return v.err()
 }
 
 // UploadVersion 小程序基于模板上传版本
@@ -391,8 +352,13 @@ func (s *MiniService) UploadVersion(ctx context.Context, biz *UploadVersionBiz,
 
 // QueryVersionDetailBiz 小程序版本详情查询
 type QueryVersionDetailBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *QueryVersionDetailBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // MiniAppCategoryInfo 小程序类目
@@ -419,15 +385,15 @@ type VersionDetail struct {
 	AppEnglishName          string                 `json:"app_english_name"`
 	AppLogo                 string                 `json:"app_logo"`
 	VersionDesc             string                 `json:"version_desc"`
-	GrayStrategy            string                 `json:"gray_strategy"`
-	Status                  string                 `json:"status"`
+	GrayStrategy            GrayStrategy           `json:"gray_strategy"`
+	Status                  VersionStatus          `json:"status"`
 	RejectReason            string                 `json:"reject_reason"`
 	ScanResult              string                 `json:"scan_result"`
-	GmtCreate               string                 `json:"gmt_create"`
-	GmtApplyAudit           string                 `json:"gmt_apply_audit"`
-	GmtOnline               string                 `json:"gmt_online"`
-	GmtOffline              string                 `json:"gmt_offline"`
-	GmtAuditEnd             string                 `json:"gmt_audit_end"`
+	GmtCreate               Time                   `json:"gmt_create"`
+	GmtApplyAudit           Time                   `json:"gmt_apply_audit"`
+	GmtOnline               Time                   `json:"gmt_online"`
+	GmtOffline              Time                   `json:"gmt_offline"`
+	GmtAuditEnd             Time                   `json:"gmt_audit_end"`
 	AppDesc                 string                 `json:"app_desc"`
 	ServiceRegionType       string                 `json:"service_region_type"`
 	ServiceRegionInfo       []*RegionInfo          `json:"service_region_info"`
@@ -457,19 +423,29 @@ func (s *MiniService) QueryVersionDetail(ctx context.Context, biz *QueryVersionD
 
 // QueryVersionBuildBiz 小程序查询版本构建状态
 type QueryVersionBuildBiz struct {
-	AppVersion string `json:"app_version"` //小程序版本号, 必选
-	BundleID   string `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+	AppVersion string   `json:"app_version"` //小程序版本号, 必选
+	BundleID   BundleID `json:"bundle_id"`   //端参数，可不选，默认支付宝端
+}
+
+// Validate 校验参数
+func (b *QueryVersionBuildBiz) Validate() error {
+	return validateAppVersion(b, b.AppVersion)
 }
 
 // QueryVersionBuildResp  小程序查询版本构建状态resp
 type QueryVersionBuildResp struct {
-	NeedRotation string `json:"need_rotation"` // 是否需要轮询
-	CreateStatus string `json:"create_status"` // 创建版本的状态，0-构建排队中；1-正在构建；2-构建成功；3-构建失败；5-构建超时；6-版本创建成功
+	NeedRotation string       `json:"need_rotation"` // 是否需要轮询
+	CreateStatus CreateStatus `json:"create_status"` // 创建版本的状态，0-构建排队中；1-正在构建；2-构建成功；3-构建失败；5-构建超时；6-版本创建成功
+}
+
+// Rotating 是否需要继续轮询
+func (r *QueryVersionBuildResp) Rotating() bool {
+	return r.NeedRotation == "true"
 }
 
 // QueryVersionBuild 小程序查询版本构建状态
 func (s *MiniService) QueryVersionBuild(ctx context.Context, biz *QueryVersionBuildBiz, opts ...ValueOptions) (*QueryVersionBuildResp, error) {
-	apiMethod := "alipay.open.mini.version.detail.query"
+	apiMethod := "alipay.open.mini.version.build.query"
 	req, err := s.client.NewRequest(apiMethod, biz, opts...)
 	if err != nil {
 		return nil, err
@@ -481,3 +457,131 @@ func (s *MiniService) QueryVersionBuild(ctx context.Context, biz *QueryVersionBu
 	}
 	return resp, nil
 }
+
+// BuildError 版本构建失败或超时
+type BuildError struct {
+	AppVersion string
+	Status     CreateStatus
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("alipay: build of version %v failed: %v (create_status %v)", e.AppVersion, e.Status, string(e.Status))
+}
+
+// WaitForBuild 上传版本后按Client.PollBackoff轮询构建状态，见WaitForBuild函数
+func (s *MiniService) WaitForBuild(ctx context.Context, appVersion string, opts ...ValueOptions) (*QueryVersionBuildResp, error) {
+	return WaitForBuild(ctx, s, appVersion, opts...)
+}
+
+// WaitForBuild 上传版本后通过mini轮询构建状态，直到不再需要轮询
+//
+// mini实现了Poller时按PollBackoff轮询，否则使用DefaultBackoff。
+// 构建失败或超时返回*BuildError，ctx结束时返回ctx.Err()。
+func WaitForBuild(ctx context.Context, mini MiniAPI, appVersion string, opts ...ValueOptions) (*QueryVersionBuildResp, error) {
+	biz := &QueryVersionBuildBiz{AppVersion: appVersion}
+	var resp *QueryVersionBuildResp
+	err := poll(ctx, pollBackoff(mini), func() (bool, error) {
+		var err error
+		resp, err = mini.QueryVersionBuild(ctx, biz, opts...)
+		if err != nil {
+			return false, err
+		}
+		return !resp.Rotating() || resp.CreateStatus.IsTerminal(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.CreateStatus.Failed() {
+		return resp, &BuildError{AppVersion: appVersion, Status: resp.CreateStatus}
+	}
+	return resp, nil
+}
+
+// VersionEvent WatchVersion发出的版本状态变化
+type VersionEvent struct {
+	AppVersion    string
+	From          VersionStatus // 上一次的状态，第一个事件为空
+	To            VersionStatus
+	RejectReason  string // 审核驳回原因
+	ScanResult    string // 扫描结果
+	GmtApplyAudit Time
+	GmtAuditEnd   Time
+	Detail        *VersionDetail // 本次查询到的版本详情
+	Err           error          // 查询失败的原因，发出后通道关闭
+}
+
+// NewVersionEvent 比较两次查询到的版本详情，状态、驳回原因、扫描结果或审核时间变化时返回事件和true
+//
+// prev为nil表示第一次查询，总会返回事件。
+func NewVersionEvent(prev, cur *VersionDetail) (VersionEvent, bool) {
+	e := VersionEvent{
+		AppVersion:    cur.AppVersion,
+		To:            cur.Status,
+		RejectReason:  cur.RejectReason,
+		ScanResult:    cur.ScanResult,
+		GmtApplyAudit: cur.GmtApplyAudit,
+		GmtAuditEnd:   cur.GmtAuditEnd,
+		Detail:        cur,
+	}
+	if prev == nil {
+		return e, true
+	}
+	e.From = prev.Status
+	changed := prev.Status != cur.Status || prev.RejectReason != cur.RejectReason || prev.ScanResult != cur.ScanResult ||
+		!prev.GmtApplyAudit.Equal(cur.GmtApplyAudit.Time) || !prev.GmtAuditEnd.Equal(cur.GmtAuditEnd.Time)
+	return e, changed
+}
+
+// WatchVersion 每隔interval查询一次版本详情，interval不大于0时按Client.PollBackoff轮询，见WatchVersion函数
+func (s *MiniService) WatchVersion(ctx context.Context, appVersion string, interval time.Duration, opts ...ValueOptions) <-chan VersionEvent {
+	return WatchVersion(ctx, s, appVersion, interval, opts...)
+}
+
+// WatchVersion 通过mini每隔interval查询一次版本详情，状态、驳回原因、扫描结果或审核时间变化时发出事件
+//
+// 第一次查询总会发出事件，之后结果不变的查询会被忽略。状态进入审核结束状态（见VersionStatus.IsTerminal）、
+// 查询失败或ctx结束时关闭通道，查询失败时关闭前会发出带Err的事件。
+// interval不大于0时，mini实现了Poller则按PollBackoff轮询，否则使用DefaultBackoff。
+func WatchVersion(ctx context.Context, mini MiniAPI, appVersion string, interval time.Duration, opts ...ValueOptions) <-chan VersionEvent {
+	events := make(chan VersionEvent)
+	go func() {
+		defer close(events)
+		send := func(e VersionEvent) bool {
+			select {
+			case events <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		b := pollBackoff(mini)
+		biz := &QueryVersionDetailBiz{AppVersion: appVersion}
+		var prev *VersionDetail
+		var wait time.Duration
+		for {
+			detail, err := mini.QueryVersionDetail(ctx, biz, opts...)
+			if err != nil {
+				if ctx.Err() == nil {
+					send(VersionEvent{AppVersion: appVersion, Err: err})
+				}
+				return
+			}
+			if e, ok := NewVersionEvent(prev, detail); ok {
+				e.AppVersion = appVersion
+				if !send(e) || detail.Status.IsTerminal() {
+					return
+				}
+			}
+			prev = detail
+			if interval > 0 {
+				wait = interval
+			} else {
+				wait = b.Next(wait)
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return
+			}
+		}
+	}()
+	return events
+}
@@ -3,10 +3,12 @@ package alipay
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestMiniService_ApplyVersionAudit(t *testing.T) {
@@ -394,8 +396,9 @@ func TestMiniService_OnlineGrayVersion(t *testing.T) {
 	})
 
 	err := client.Mini.OnlineGrayVersion(context.Background(), &OnlineGrayVersionBiz{
-		AppVersion: "0.0.1",
-		BundleID:   "com.alipay.alipaywallet",
+		AppVersion:   "0.0.1",
+		GrayStrategy: "p10",
+		BundleID:     "com.alipay.alipaywallet",
 	})
 	if err != nil {
 		t.Errorf("Mini.OfflineVersion returned unexcepted error: %v", err)
@@ -418,8 +421,9 @@ func TestMiniService_OnlineGrayVersion_failed(t *testing.T) {
 						}`)
 	})
 	err := client.Mini.OnlineGrayVersion(context.Background(), &OnlineGrayVersionBiz{
-		AppVersion: "0.0.1",
-		BundleID:   "com.alipay.alipaywallet",
+		AppVersion:   "0.0.1",
+		GrayStrategy: "p10",
+		BundleID:     "com.alipay.alipaywallet",
 	})
 	if err == nil {
 		t.Errorf("Mini.OnlineGrayVersion excepted error")
@@ -554,11 +558,11 @@ func TestMiniService_QueryVersionDetail(t *testing.T) {
 		Status:            "INIT",
 		RejectReason:      "名称太宽泛",
 		ScanResult:        "True",
-		GmtCreate:         "2017-12-12 12:00:00",
-		GmtApplyAudit:     "2017-12-12 12:00:00",
-		GmtOnline:         "2017-12-12 12:00:00",
-		GmtOffline:        "2017-12-12 12:00:00",
-		GmtAuditEnd:       "2017-12-12 12:00:00",
+		GmtCreate:         mustParseTime("2017-12-12 12:00:00"),
+		GmtApplyAudit:     mustParseTime("2017-12-12 12:00:00"),
+		GmtOnline:         mustParseTime("2017-12-12 12:00:00"),
+		GmtOffline:        mustParseTime("2017-12-12 12:00:00"),
+		GmtAuditEnd:       mustParseTime("2017-12-12 12:00:00"),
 		AppDesc:           "小程序demo的相关示例",
 		ServiceRegionType: "LOCATION",
 		ServiceRegionInfo: []*RegionInfo{
@@ -825,3 +829,177 @@ func TestMiniService_QueryVersionBuild_failed(t *testing.T) {
 		t.Errorf("Mini.QueryVersionBuild excepted error")
 	}
 }
+
+func TestMiniService_WaitForBuild(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, Multiplier: 2}
+
+	statuses := []string{"0", "1", "6"}
+	var calls int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("method"); got != "alipay.open.mini.version.build.query" {
+			t.Errorf("method got %v, want alipay.open.mini.version.build.query", got)
+		}
+		status := statuses[calls]
+		calls++
+		fmt.Fprintf(w, `{
+							"alipay_open_mini_version_build_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"need_rotation": "%v",
+								"create_status": "%v"
+							}
+						}`, status != "6", status)
+	})
+
+	got, err := client.Mini.WaitForBuild(context.Background(), "0.0.1")
+	if err != nil {
+		t.Fatalf("Mini.WaitForBuild returned unexcepted error: %v", err)
+	}
+	if got.CreateStatus != CreateStatusCreated || calls != 3 {
+		t.Errorf("Mini.WaitForBuild got %+v after %d calls, want created after 3", got, calls)
+	}
+}
+
+func TestMiniService_WaitForBuild_failed(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Millisecond}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_build_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"need_rotation": "false",
+								"create_status": "5"
+							}
+						}`)
+	})
+
+	_, err := client.Mini.WaitForBuild(context.Background(), "0.0.1")
+	var buildErr *BuildError
+	if !errors.As(err, &buildErr) || buildErr.Status != CreateStatusBuildTimeout || buildErr.AppVersion != "0.0.1" {
+		t.Errorf("Mini.WaitForBuild got %v, want *BuildError", err)
+	}
+}
+
+func TestMiniService_WaitForBuild_canceled(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.PollBackoff = &Backoff{Initial: time.Hour}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_build_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"need_rotation": "true",
+								"create_status": "1"
+							}
+						}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Mini.WaitForBuild(ctx, "0.0.1"); err != context.DeadlineExceeded {
+		t.Errorf("Mini.WaitForBuild got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestMiniService_WatchVersion(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	responses := []string{
+		`"status": "INIT"`,
+		`"status": "AUDITING", "gmt_apply_audit": "2020-04-19 22:41:12"`,
+		`"status": "AUDITING", "gmt_apply_audit": "2020-04-19 22:41:12"`,
+		`"status": "AUDIT_REJECT", "gmt_apply_audit": "2020-04-19 22:41:12", "gmt_audit_end": "2020-04-20 10:00:00", "reject_reason": "内容违规"`,
+	}
+	var calls int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("method"); got != "alipay.open.mini.version.detail.query" {
+			t.Errorf("method got %v, want alipay.open.mini.version.detail.query", got)
+		}
+		resp := responses[calls]
+		calls++
+		fmt.Fprintf(w, `{
+							"alipay_open_mini_version_detail_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"app_version": "0.0.1",
+								%v
+							}
+						}`, resp)
+	})
+
+	var got []VersionEvent
+	for e := range client.Mini.WatchVersion(context.Background(), "0.0.1", time.Millisecond) {
+		got = append(got, e)
+	}
+	if len(got) != 3 || calls != 4 {
+		t.Fatalf("Mini.WatchVersion got %d events after %d calls, want 3 after 4", len(got), calls)
+	}
+	if got[0].From != "" || got[0].To != VersionStatusInit {
+		t.Errorf("first event got %+v, want INIT", got[0])
+	}
+	if got[1].From != VersionStatusInit || got[1].To != VersionStatusAuditing || got[1].GmtApplyAudit.IsZero() {
+		t.Errorf("second event got %+v, want INIT -> AUDITING", got[1])
+	}
+	last := got[2]
+	if last.From != VersionStatusAuditing || last.To != VersionStatusAuditReject || last.RejectReason != "内容违规" ||
+		last.GmtAuditEnd.String() != "2020-04-20 10:00:00" || last.Detail == nil || last.Err != nil {
+		t.Errorf("last event got %+v, want AUDITING -> AUDIT_REJECT", last)
+	}
+}
+
+func TestMiniService_WatchVersion_failed(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_detail_query_response": {
+								"code": "20000",
+								"msg": "Service Currently Unavailable",
+								"sub_code": "isp.unknow-error",
+								"sub_msg": "系统繁忙"
+							}
+						}`)
+	})
+
+	var got []VersionEvent
+	for e := range client.Mini.WatchVersion(context.Background(), "0.0.1", time.Millisecond) {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].Err == nil || got[0].AppVersion != "0.0.1" {
+		t.Errorf("Mini.WatchVersion got %+v, want one error event", got)
+	}
+}
+
+func TestMiniService_WatchVersion_canceled(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_detail_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"status": "AUDITING"
+							}
+						}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.Mini.WatchVersion(ctx, "0.0.1", time.Hour)
+	if e := <-events; e.To != VersionStatusAuditing {
+		t.Errorf("first event got %+v, want AUDITING", e)
+	}
+	cancel()
+	if _, ok := <-events; ok {
+		t.Errorf("Mini.WatchVersion channel not closed after cancel")
+	}
+}
@@ -0,0 +1,312 @@
+package alipay
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sniffLen http.DetectContentType最多读取的字节数
+const sniffLen = 512
+
+// FileTooLargeError 上传文件超过Client.MaxUploadSize
+type FileTooLargeError struct {
+	Field string // 表单字段名
+	Name  string // 文件名
+	Size  int64  // 文件大小，上传过程中才发现超限时为-1
+	Limit int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("alipay: file %q (%v) exceeds upload limit of %d bytes", e.Name, e.Field, e.Limit)
+	}
+	return fmt.Sprintf("alipay: file %q (%v) is %d bytes, exceeds upload limit of %d bytes", e.Name, e.Field, e.Size, e.Limit)
+}
+
+// multipartPart 一个文件表单项
+type multipartPart struct {
+	field       string
+	name        string
+	contentType string
+	size        int64     // 未知时为-1
+	r           io.Reader // 调用方提供的内容，file不为nil时不使用
+	seeker      io.Seeker // 为nil时无法重新读取
+	offset      int64
+	file        *File // 通过构造函数创建的文件，每次打开请求体时使用新的Reader
+}
+
+// multipartBody 以流的方式生成multipart请求体，不在内存中缓存文件内容
+//
+// 普通字段按key排序写在前面，文件按字段名排序写在后面。
+type multipartBody struct {
+	boundary string
+	fields   url.Values
+	parts    []*multipartPart
+	limit    int64
+
+	mu     sync.Mutex
+	opened bool
+}
+
+func newMultipartBody(boundary string, fields url.Values, files map[string]io.Reader, limit int64) (*multipartBody, error) {
+	b := &multipartBody{boundary: boundary, fields: fields, limit: limit}
+	for _, key := range sortedKeys(files) {
+		f, ok := files[key].(*File)
+		if !ok && files[key] != nil {
+			f = &File{Name: key, Content: files[key]}
+		}
+		if f == nil || f.Content == nil {
+			return nil, fmt.Errorf("alipay: multipart param %q must have content", key)
+		}
+		p := &multipartPart{field: key, name: f.Name, size: -1}
+		if f.owned() {
+			p.file = f
+			p.size = f.Size()
+			p.contentType = f.ContentType()
+		} else {
+			p.r = f.Content
+			if err := p.inspect(); err != nil {
+				return nil, err
+			}
+		}
+		if limit > 0 && p.size > limit {
+			return nil, &FileTooLargeError{Field: key, Name: f.Name, Size: p.size, Limit: limit}
+		}
+		b.parts = append(b.parts, p)
+	}
+	return b, nil
+}
+
+// inspect 获取文件大小并探测Content-Type，可Seek的内容读取后会回到原位置
+func (p *multipartPart) inspect() error {
+	if s, ok := p.r.(io.Seeker); ok {
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			p.seeker = s
+			p.offset = offset
+		}
+	}
+	if l, ok := p.r.(interface{ Len() int }); ok {
+		p.size = int64(l.Len())
+	} else if p.seeker != nil {
+		end, err := p.seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		p.size = end - p.offset
+		if _, err = p.seeker.Seek(p.offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	var head []byte
+	if p.seeker != nil {
+		buf := make([]byte, sniffLen)
+		n, err := io.ReadFull(p.r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		head = buf[:n]
+		if _, err = p.seeker.Seek(p.offset, io.SeekStart); err != nil {
+			return err
+		}
+	} else {
+		br := bufio.NewReaderSize(p.r, sniffLen)
+		head, _ = br.Peek(sniffLen)
+		p.r = br
+	}
+	p.contentType = detectContentType(p.name, head)
+	return nil
+}
+
+// detectContentType 按内容探测类型，无法识别时按扩展名判断
+func detectContentType(name string, head []byte) string {
+	contentType := "application/octet-stream"
+	if len(head) > 0 {
+		contentType = http.DetectContentType(head)
+	}
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(name)); byExt != "" {
+			return byExt
+		}
+	}
+	return contentType
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func (p *multipartPart) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(p.field), quoteEscaper.Replace(p.name)))
+	h.Set("Content-Type", p.contentType)
+	return h
+}
+
+// contentType 请求的Content-Type
+func (b *multipartBody) contentType() string {
+	return "multipart/form-data; boundary=" + b.boundary
+}
+
+// contentLength 所有文件大小已知时返回请求体长度，否则返回-1
+func (b *multipartBody) contentLength() int64 {
+	var size int64
+	for _, p := range b.parts {
+		if p.size < 0 {
+			return -1
+		}
+		size += p.size
+	}
+	cw := &countingWriter{}
+	if err := b.write(cw, nil); err != nil {
+		return -1
+	}
+	return cw.n + size
+}
+
+// open 返回新的请求体，再次调用时会将文件回到起始位置，用于http.Request.GetBody
+//
+// 通过构造函数创建的文件每次都使用新的Reader从头读取，不修改File本身。
+func (b *multipartBody) open() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	readers := make([]io.Reader, len(b.parts))
+	// 通过路径创建的文件在读取时打开，请求结束、取消或写入失败时需要关闭
+	var closers []io.Closer
+	for i, p := range b.parts {
+		if p.file != nil {
+			readers[i] = p.file.reader()
+			if c, ok := readers[i].(io.Closer); ok {
+				closers = append(closers, c)
+			}
+			continue
+		}
+		if b.opened {
+			if p.seeker == nil {
+				return nil, fmt.Errorf("alipay: multipart file %q cannot be re-read", p.field)
+			}
+			if _, err := p.seeker.Seek(p.offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		readers[i] = p.r
+	}
+	b.opened = true
+	return &lazyPipe{write: func(w io.Writer) error { return b.write(w, readers) }, closers: closers}, nil
+}
+
+// write 写出请求体，readers为各文件的内容，为nil时只写文件头用于计算长度
+func (b *multipartBody) write(w io.Writer, readers []io.Reader) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+	for _, k := range sortedKeys(b.fields) {
+		if err := mw.WriteField(k, b.fields.Get(k)); err != nil {
+			return err
+		}
+	}
+	for i, p := range b.parts {
+		pw, err := mw.CreatePart(p.header())
+		if err != nil {
+			return err
+		}
+		if readers == nil {
+			continue
+		}
+		src := readers[i]
+		if b.limit > 0 {
+			src = io.LimitReader(src, b.limit+1)
+		}
+		n, err := io.Copy(pw, src)
+		if err != nil {
+			return err
+		}
+		if b.limit > 0 && n > b.limit {
+			return &FileTooLargeError{Field: p.field, Name: p.name, Size: -1, Limit: b.limit}
+		}
+	}
+	return mw.Close()
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// lazyPipe 第一次读取时才启动写入goroutine的io.Pipe，未发送的请求不会遗留goroutine
+//
+// 写入失败或关闭时会关闭closers，避免请求取消或失败时遗留打开的文件。
+type lazyPipe struct {
+	write   func(w io.Writer) error
+	closers []io.Closer
+
+	mu     sync.Mutex
+	pr     *io.PipeReader
+	done   chan struct{}
+	closed bool
+}
+
+func (p *lazyPipe) reader() (*io.PipeReader, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("alipay: read on closed request body")
+	}
+	if p.pr == nil {
+		pr, pw := io.Pipe()
+		p.pr = pr
+		p.done = make(chan struct{})
+		go func() {
+			defer close(p.done)
+			err := p.write(pw)
+			if err != nil {
+				p.closeAll()
+			}
+			pw.CloseWithError(err)
+		}()
+	}
+	return p.pr, nil
+}
+
+func (p *lazyPipe) Read(b []byte) (int, error) {
+	pr, err := p.reader()
+	if err != nil {
+		return 0, err
+	}
+	return pr.Read(b)
+}
+
+// Close 关闭读取端，等待写入goroutine退出后关闭closers
+func (p *lazyPipe) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	pr, done := p.pr, p.done
+	p.mu.Unlock()
+	if pr != nil {
+		pr.Close()
+		<-done
+	}
+	p.closeAll()
+	return nil
+}
+
+func (p *lazyPipe) closeAll() {
+	for _, c := range p.closers {
+		c.Close()
+	}
+}
@@ -0,0 +1,141 @@
+package alipay
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func readParts(t *testing.T, req *http.Request, body []byte) (names []string, contentTypes map[string]string) {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType returned unexcepted error: %v", err)
+	}
+	contentTypes = make(map[string]string)
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart returned unexcepted error: %v", err)
+		}
+		names = append(names, part.FormName())
+		if part.FileName() != "" {
+			contentTypes[part.FormName()] = part.Header.Get("Content-Type")
+		}
+	}
+	return names, contentTypes
+}
+
+func TestClient_NewRequest_multipart(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	req, err := c.NewRequest("alipay.open.mini.version.audit.apply", &ApplyVersionAuditBiz{
+		AppVersion:      "0.0.1",
+		VersionDesc:     "小程序版本描述",
+		FirstScreenShot: &File{Name: "1.png", Content: bytes.NewReader(pngHeader)},
+		AppLogo:         &File{Name: "logo.jpg", Content: bytes.NewReader([]byte("\xFF\xD8\xFFlogo"))},
+		TestFileName:    &File{Name: "account.txt", Content: io.MultiReader(strings.NewReader("account"))},
+	})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	if req.GetBody == nil {
+		t.Fatalf("NewRequest did not set GetBody")
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	if req.ContentLength != -1 {
+		t.Errorf("ContentLength got %v, want -1 for unknown file size", req.ContentLength)
+	}
+
+	names, contentTypes := readParts(t, req, body)
+	want := []string{
+		"app_id", "app_version", "charset", "format", "method", "sign", "sign_type", "timestamp", "version", "version_desc",
+		"app_logo", "first_screen_shot", "test_file_name",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("parts got %v, want %v", names, want)
+	}
+	wantTypes := map[string]string{
+		"app_logo":          "image/jpeg",
+		"first_screen_shot": "image/png",
+		"test_file_name":    "text/plain; charset=utf-8",
+	}
+	if !reflect.DeepEqual(contentTypes, wantTypes) {
+		t.Errorf("part content types got %v, want %v", contentTypes, wantTypes)
+	}
+
+	if _, err := req.GetBody(); err == nil {
+		t.Errorf("GetBody excepted error for non-seekable file")
+	}
+}
+
+func TestClient_NewRequest_multipartGetBody(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{
+		AppName: "小程序示例",
+		AppLogo: &File{Name: "logo.png", Content: bytes.NewReader(pngHeader)},
+	})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	first, _ := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if req.ContentLength != int64(len(first)) {
+		t.Errorf("ContentLength got %v, want %v", req.ContentLength, len(first))
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		t.Fatalf("GetBody returned unexcepted error: %v", err)
+	}
+	second, _ := ioutil.ReadAll(rc)
+	if !bytes.Equal(first, second) {
+		t.Errorf("GetBody got %q, want %q", second, first)
+	}
+}
+
+func TestClient_NewRequest_maxUploadSize(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	c.MaxUploadSize = 4
+
+	_, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{
+		AppLogo: &File{Name: "logo.png", Content: bytes.NewReader(pngHeader)},
+	})
+	var tooLarge *FileTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != int64(len(pngHeader)) || tooLarge.Field != "app_logo" {
+		t.Errorf("NewRequest got %v, want *FileTooLargeError", err)
+	}
+
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{
+		AppLogo: &File{Name: "logo.png", Content: io.MultiReader(bytes.NewReader(pngHeader))},
+	})
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	_, err = ioutil.ReadAll(req.Body)
+	if !errors.As(err, &tooLarge) || tooLarge.Size != -1 {
+		t.Errorf("reading body got %v, want *FileTooLargeError", err)
+	}
+}
+
+func TestClient_NewRequest_multipartInvalidParam(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	_, err := c.NewRequest("alipay.open.mini.baseinfo.modify", &ModifyBaseInfoBiz{
+		AppLogo: &File{Name: "logo.png"},
+	})
+	if err == nil {
+		t.Errorf("NewRequest excepted error for file without content")
+	}
+}
@@ -0,0 +1,86 @@
+package alipay
+
+import (
+	"context"
+	"time"
+)
+
+// Backoff 轮询间隔，从Initial开始每次乘以Multiplier，不超过Max
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// DefaultBackoff 默认的轮询间隔
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+
+// Next 返回下一次轮询前的等待时间，prev为0时返回Initial，Initial不大于0时使用DefaultBackoff.Initial
+func (b Backoff) Next(prev time.Duration) time.Duration {
+	if prev <= 0 {
+		if b.Initial <= 0 {
+			return DefaultBackoff.Initial
+		}
+		return b.Initial
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := time.Duration(float64(prev) * multiplier)
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+func (c *Client) backoff() Backoff {
+	if c.PollBackoff != nil {
+		return *c.PollBackoff
+	}
+	return DefaultBackoff
+}
+
+// Poller 可以指定轮询间隔的MiniAPI实现，WaitForBuild等轮询函数按PollBackoff轮询，未实现时使用DefaultBackoff
+type Poller interface {
+	PollBackoff() Backoff
+}
+
+// PollBackoff 返回Client.PollBackoff，为nil时返回DefaultBackoff
+func (s *MiniService) PollBackoff() Backoff {
+	return s.client.backoff()
+}
+
+func pollBackoff(mini MiniAPI) Backoff {
+	if p, ok := mini.(Poller); ok {
+		return p.PollBackoff()
+	}
+	return DefaultBackoff
+}
+
+// sleep 等待d或ctx结束
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// poll 按b重复调用check直到done为true、出错或ctx结束
+func poll(ctx context.Context, b Backoff, check func() (done bool, err error)) error {
+	var wait time.Duration
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		wait = b.Next(wait)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
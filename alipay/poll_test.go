@@ -0,0 +1,37 @@
+package alipay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_Next(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	var got []time.Duration
+	var d time.Duration
+	for i := 0; i < 5; i++ {
+		d = b.Next(d)
+		got = append(got, d)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Next got %v, want %v", got, want)
+			break
+		}
+	}
+
+	constant := Backoff{Initial: time.Millisecond}
+	if d := constant.Next(constant.Next(0)); d != time.Millisecond {
+		t.Errorf("Next got %v, want constant interval without Multiplier", d)
+	}
+
+	for _, b := range []Backoff{{}, {Initial: -time.Second, Multiplier: 2}} {
+		if d := b.Next(0); d != DefaultBackoff.Initial {
+			t.Errorf("%+v Next got %v, want DefaultBackoff.Initial", b, d)
+		}
+		if d := b.Next(b.Next(0)); d <= 0 {
+			t.Errorf("%+v Next got %v, want positive interval", b, d)
+		}
+	}
+}
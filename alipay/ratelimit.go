@@ -0,0 +1,232 @@
+package alipay
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ThrottleSubCodes 支付宝返回的限流类子错误码，命中时限流器会对该接口自动退避
+var ThrottleSubCodes = []string{
+	"isp.call-limited",
+	"isv.app-call-limited",
+	"aop.call-limited",
+}
+
+// RateLimit 令牌桶限流配置
+type RateLimit struct {
+	Rate  float64 // 每秒补充的令牌数，小于等于0表示不限速
+	Burst int     // 令牌桶容量，小于1时按1处理
+}
+
+// RateLimiter 客户端限流器
+//
+// 按接口方法分别限流，使用AppAuthToken代商户调用时，每个商户应用单独一个令牌桶。
+// 支付宝返回限流错误时，对应的令牌桶会暂停发放令牌并指数退避，调用成功后恢复。
+// 空闲超过IdleTimeout的令牌桶会被移除，避免代大量商户调用时令牌桶无限增长。
+// 零值可以直接使用，未通过SetLimit设置的接口不限速。
+type RateLimiter struct {
+	BackOff     time.Duration // 首次退避时间，默认1秒
+	MaxBackOff  time.Duration // 最大退避时间，默认1分钟
+	IdleTimeout time.Duration // 令牌桶空闲多久后移除，默认10分钟
+
+	mu      sync.Mutex
+	limits  map[string]RateLimit
+	buckets map[string]*bucket
+	swept   time.Time // 上次移除空闲令牌桶的时间
+}
+
+// NewRateLimiter 创建限流器，limits的key为接口方法名，例如alipay.open.mini.version.upload
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		limits:  make(map[string]RateLimit),
+		buckets: make(map[string]*bucket),
+	}
+	for method, limit := range limits {
+		l.limits[method] = limit
+	}
+	return l
+}
+
+// SetLimit 设置接口方法的限流配置，已创建的令牌桶会按新配置重建
+func (l *RateLimiter) SetLimit(method string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits == nil {
+		l.limits = make(map[string]RateLimit)
+	}
+	l.limits[method] = limit
+	for key, b := range l.buckets {
+		if b.method == method {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Wait 阻塞直到接口方法可以发起请求，ctx取消或超时时返回ctx.Err()
+func (l *RateLimiter) Wait(ctx context.Context, method, appAuthToken string) error {
+	now := time.Now()
+	b := l.bucket(method, appAuthToken, now)
+	d := b.reserve(now)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// report 根据请求结果调整退避状态
+func (l *RateLimiter) report(method, appAuthToken string, err error) {
+	now := time.Now()
+	b := l.bucket(method, appAuthToken, now)
+	if e, ok := err.(*ErrorResponse); ok && isThrottled(e) {
+		min, max := l.backOffRange()
+		b.backOff(now, min, max)
+		return
+	}
+	if err == nil {
+		b.resetBackOff()
+	}
+}
+
+func (l *RateLimiter) backOffRange() (time.Duration, time.Duration) {
+	min, max := l.BackOff, l.MaxBackOff
+	if min <= 0 {
+		min = time.Second
+	}
+	if max <= 0 {
+		max = time.Minute
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+func (l *RateLimiter) bucket(method, appAuthToken string, now time.Time) *bucket {
+	key := method + "|" + appAuthToken
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	l.evict(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		limit := l.limits[method]
+		b = &bucket{
+			method: method,
+			rate:   limit.Rate,
+			burst:  math.Max(float64(limit.Burst), 1),
+		}
+		b.tokens = b.burst
+		l.buckets[key] = b
+	}
+	b.used = now
+	return b
+}
+
+// evict 移除空闲的令牌桶，每个IdleTimeout最多扫描一次
+func (l *RateLimiter) evict(now time.Time) {
+	ttl := l.IdleTimeout
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	if now.Sub(l.swept) < ttl {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if now.Sub(b.used) >= ttl && b.idle(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func isThrottled(e *ErrorResponse) bool {
+	for _, code := range ThrottleSubCodes {
+		if e.SubCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+type bucket struct {
+	mu     sync.Mutex
+	method string
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	backOffDelay time.Duration
+	pausedUntil  time.Time
+
+	used time.Time // 最后使用时间，由RateLimiter.mu保护
+}
+
+// reserve 预定一个令牌，返回需要等待的时间
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var wait time.Duration
+	if b.rate > 0 {
+		if !b.last.IsZero() {
+			b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+	if paused := b.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	return wait
+}
+
+// idle 令牌桶已经补满且不在退避中，移除后重建不影响限流
+func (b *bucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.pausedUntil) || b.backOffDelay > 0 {
+		return false
+	}
+	return b.rate <= 0 || b.last.IsZero() || b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// cancel 归还未使用的令牌
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+}
+
+func (b *bucket) backOff(now time.Time, min, max time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.backOffDelay == 0 {
+		b.backOffDelay = min
+	} else if b.backOffDelay *= 2; b.backOffDelay > max {
+		b.backOffDelay = max
+	}
+	b.pausedUntil = now.Add(b.backOffDelay)
+}
+
+func (b *bucket) resetBackOff() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.backOffDelay = 0
+}
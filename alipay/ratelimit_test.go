@@ -0,0 +1,134 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := NewRateLimiter(map[string]RateLimit{
+		"foo": {Rate: 20, Burst: 2},
+	})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "foo", ""); err != nil {
+			t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+		}
+	}
+	if got := time.Since(start); got < 40*time.Millisecond {
+		t.Errorf("RateLimiter.Wait waited %v, want at least 40ms", got)
+	}
+
+	start = time.Now()
+	if err := l.Wait(ctx, "foo", "merchant"); err != nil {
+		t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+	}
+	if err := l.Wait(ctx, "bar", ""); err != nil {
+		t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+	}
+	if got := time.Since(start); got > 20*time.Millisecond {
+		t.Errorf("RateLimiter.Wait on independent buckets waited %v", got)
+	}
+}
+
+func TestRateLimiter_zeroValue(t *testing.T) {
+	l := &RateLimiter{}
+	if err := l.Wait(context.Background(), "foo", ""); err != nil {
+		t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+	}
+	l.SetLimit("foo", RateLimit{Rate: 20, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background(), "foo", ""); err != nil {
+			t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+		}
+	}
+	if got := time.Since(start); got < 40*time.Millisecond {
+		t.Errorf("RateLimiter.Wait waited %v, want at least 40ms", got)
+	}
+}
+
+func TestRateLimiter_Wait_canceled(t *testing.T) {
+	l := NewRateLimiter(map[string]RateLimit{
+		"foo": {Rate: 0.1, Burst: 1},
+	})
+	_ = l.Wait(context.Background(), "foo", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "foo", ""); err != context.DeadlineExceeded {
+		t.Errorf("RateLimiter.Wait got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClient_Do_rateLimitBackOff(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+	client.RateLimiter = NewRateLimiter(nil)
+	client.RateLimiter.BackOff = 50 * time.Millisecond
+
+	calls := 0
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "40004",
+								"msg": "Business Failed",
+								"sub_code": "isp.call-limited",
+								"sub_msg": "调用频率超限"
+							}
+						}`)
+			return
+		}
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"app_versions": ["0.0.1"]
+							}
+						}`)
+	})
+
+	if _, err := client.Mini.QueryVersionList(context.Background()); err == nil {
+		t.Fatalf("Mini.QueryVersionList excepted error")
+	}
+	start := time.Now()
+	if _, err := client.Mini.QueryVersionList(context.Background()); err != nil {
+		t.Fatalf("Mini.QueryVersionList returned unexcepted error: %v", err)
+	}
+	if got := time.Since(start); got < 40*time.Millisecond {
+		t.Errorf("Client.Do after throttling waited %v, want at least 40ms", got)
+	}
+}
+
+func TestRateLimiter_evictIdle(t *testing.T) {
+	l := NewRateLimiter(map[string]RateLimit{
+		"foo": {Rate: 1000, Burst: 1},
+	})
+	l.IdleTimeout = 20 * time.Millisecond
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, "foo", fmt.Sprintf("merchant%d", i)); err != nil {
+			t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+		}
+	}
+	l.report("bar", "throttled", &ErrorResponse{SubCode: "isp.call-limited"})
+	time.Sleep(30 * time.Millisecond)
+
+	if err := l.Wait(ctx, "foo", "merchant0"); err != nil {
+		t.Fatalf("RateLimiter.Wait returned unexcepted error: %v", err)
+	}
+	l.mu.Lock()
+	n := len(l.buckets)
+	l.mu.Unlock()
+	// 退避中的令牌桶不会被移除
+	if n != 2 {
+		t.Errorf("RateLimiter has %d buckets after idle timeout, want 2", n)
+	}
+}
@@ -0,0 +1,158 @@
+package alipay
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ./internal/regiongen -in internal/regiongen/gbt2260.txt -out region_data.go
+
+// region 行政区划，代码为6位数字，省级以0000结尾，市级以00结尾
+type region struct {
+	code     string
+	name     string
+	parent   *region
+	children []*region
+}
+
+var (
+	regionsOnce  sync.Once
+	regionByCode map[string]*region
+	regionRoots  []*region
+)
+
+// regions 解析内置的行政区划数据
+func regions() map[string]*region {
+	regionsOnce.Do(func() {
+		regionByCode = make(map[string]*region)
+		for _, line := range strings.Split(regionData, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			r := &region{code: fields[0], name: fields[1]}
+			if r.parent = regionByCode[parentRegionCode(r.code)]; r.parent != nil {
+				r.parent.children = append(r.parent.children, r)
+			} else {
+				regionRoots = append(regionRoots, r)
+			}
+			regionByCode[r.code] = r
+		}
+	})
+	return regionByCode
+}
+
+// parentRegionCode 返回上级区划代码，省级区划返回空字符串
+func parentRegionCode(code string) string {
+	switch {
+	case len(code) != 6 || strings.HasSuffix(code, "0000"):
+		return ""
+	case strings.HasSuffix(code, "00"):
+		return code[:2] + "0000"
+	default:
+		return code[:4] + "00"
+	}
+}
+
+// info 返回从省级到当前区划的RegionInfo
+func (r *region) info() *RegionInfo {
+	info := new(RegionInfo)
+	for node := r; node != nil; node = node.parent {
+		switch {
+		case strings.HasSuffix(node.code, "0000"):
+			info.ProvinceCode, info.ProvinceName = node.code, node.name
+		case strings.HasSuffix(node.code, "00"):
+			info.CityCode, info.CityName = node.code, node.name
+		default:
+			info.AreaCode, info.AreaName = node.code, node.name
+		}
+	}
+	return info
+}
+
+// RegionByCode 按省、市或区县代码返回RegionInfo，例如RegionByCode("330106")
+//
+// 市级代码只填写省市，省级代码只填写省。
+func RegionByCode(code string) (*RegionInfo, error) {
+	r, ok := regions()[code]
+	if !ok {
+		return nil, fmt.Errorf("alipay: region %q not found", code)
+	}
+	return r.info(), nil
+}
+
+// RegionByName 按从省级开始的名称返回RegionInfo，例如RegionByName("浙江省", "杭州市", "西湖区")
+func RegionByName(names ...string) (*RegionInfo, error) {
+	regions()
+	if len(names) == 0 || len(names) > 3 {
+		return nil, fmt.Errorf("alipay: region names %v out of range [1, 3]", names)
+	}
+	level := regionRoots
+	var found *region
+	for _, name := range names {
+		found = nil
+		for _, r := range level {
+			if r.name == name {
+				found = r
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("alipay: region %q not found", strings.Join(names, "/"))
+		}
+		level = found.children
+	}
+	return found.info(), nil
+}
+
+// ValidateRegionInfo 使用内置的行政区划数据校验省市区的代码和名称
+//
+// 代码必须存在且与名称一致，下级代码必须属于上级区划，不填写区县时可以只填写省市。
+// 请求参数的Validate不会调用，可以在构造ServiceRegionInfo后调用，CheckAudit也会调用。
+func ValidateRegionInfo(infos ...*RegionInfo) error {
+	regions()
+	v := &validation{biz: "RegionInfo"}
+	for i, info := range infos {
+		field := fmt.Sprintf("service_region_info[%d]", i)
+		if info == nil {
+			v.check(false, field, "is required")
+			continue
+		}
+		ok := v.region(field+".province_code", info.ProvinceCode, info.ProvinceName, "")
+		if info.CityCode == "" {
+			v.check(info.CityName == "" && info.AreaCode == "" && info.AreaName == "", field+".city_code", "is required")
+			continue
+		}
+		if !ok {
+			continue
+		}
+		ok = v.region(field+".city_code", info.CityCode, info.CityName, info.ProvinceCode)
+		if info.AreaCode == "" {
+			v.check(info.AreaName == "", field+".area_code", "is required")
+			continue
+		}
+		if ok {
+			v.region(field+".area_code", info.AreaCode, info.AreaName, info.CityCode)
+		}
+	}
+	return v.err()
+}
+
+// region 校验区划代码属于parentCode，存在于内置数据中且与名称一致
+func (v *validation) region(field, code, name, parentCode string) bool {
+	if !v.required(field, code) {
+		return false
+	}
+	if len(code) != 6 || strings.Trim(code, "0123456789") != "" || parentRegionCode(code) != parentCode {
+		v.check(false, field, "%q is not a valid region code", code)
+		return false
+	}
+	r, ok := regions()[code]
+	if !ok {
+		v.check(false, field, "region %q not found", code)
+		return false
+	}
+	v.check(name == r.name, field, "name %q does not match %v %v", name, code, r.name)
+	return true
+}
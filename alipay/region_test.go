@@ -0,0 +1,99 @@
+package alipay
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRegionByCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want *RegionInfo
+	}{
+		{"330106", &RegionInfo{
+			ProvinceCode: "330000", ProvinceName: "浙江省",
+			CityCode: "330100", CityName: "杭州市",
+			AreaCode: "330106", AreaName: "西湖区",
+		}},
+		{"110105", &RegionInfo{
+			ProvinceCode: "110000", ProvinceName: "北京市",
+			CityCode: "110100", CityName: "北京市",
+			AreaCode: "110105", AreaName: "朝阳区",
+		}},
+		{"440300", &RegionInfo{ProvinceCode: "440000", ProvinceName: "广东省", CityCode: "440300", CityName: "深圳市"}},
+		{"510000", &RegionInfo{ProvinceCode: "510000", ProvinceName: "四川省"}},
+	}
+	for _, tt := range tests {
+		got, err := RegionByCode(tt.code)
+		if err != nil {
+			t.Errorf("RegionByCode(%v) returned unexcepted error: %v", tt.code, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RegionByCode(%v) got %+v, want %+v", tt.code, got, tt.want)
+		}
+		if err := ValidateRegionInfo(got); err != nil {
+			t.Errorf("ValidateRegionInfo(%v) returned unexcepted error: %v", tt.code, err)
+		}
+	}
+	if _, err := RegionByCode("339999"); err == nil {
+		t.Errorf("RegionByCode excepted error")
+	}
+}
+
+func TestRegionByName(t *testing.T) {
+	got, err := RegionByName("浙江省", "杭州市", "西湖区")
+	if err != nil {
+		t.Fatalf("RegionByName returned unexcepted error: %v", err)
+	}
+	if want, _ := RegionByCode("330106"); !reflect.DeepEqual(got, want) {
+		t.Errorf("RegionByName got %+v, want %+v", got, want)
+	}
+	if got, _ := RegionByName("上海市", "上海市"); got == nil || got.CityCode != "310100" {
+		t.Errorf("RegionByName got %+v, want 310100", got)
+	}
+	for _, names := range [][]string{nil, {"浙江省", "西湖区"}, {"浙江省", "杭州市", "西湖区", "西湖"}} {
+		if _, err := RegionByName(names...); err == nil {
+			t.Errorf("RegionByName(%v) excepted error", names)
+		}
+	}
+}
+
+func TestValidateRegionInfo(t *testing.T) {
+	valid := []*RegionInfo{
+		{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "330100", CityName: "杭州市", AreaCode: "330106", AreaName: "西湖区"},
+		{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "330300", CityName: "温州市", AreaCode: "330302", AreaName: "鹿城区"},
+		{ProvinceCode: "510000", ProvinceName: "四川省", CityCode: "510100", CityName: "成都市"},
+		{ProvinceCode: "500000", ProvinceName: "重庆市", CityCode: "500200", CityName: "县", AreaCode: "500229", AreaName: "城口县"},
+		{ProvinceCode: "650000", ProvinceName: "新疆维吾尔自治区", CityCode: "659000", CityName: "自治区直辖县级行政区划", AreaCode: "659001", AreaName: "石河子市"},
+		{ProvinceCode: "440000", ProvinceName: "广东省", CityCode: "441900", CityName: "东莞市"},
+	}
+	if err := ValidateRegionInfo(valid...); err != nil {
+		t.Errorf("ValidateRegionInfo returned unexcepted error: %v", err)
+	}
+
+	tests := []struct {
+		info  *RegionInfo
+		field string
+	}{
+		{nil, "service_region_info[0]"},
+		{&RegionInfo{ProvinceName: "浙江省"}, "service_region_info[0].province_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "江苏省"}, "service_region_info[0].province_code"},
+		{&RegionInfo{ProvinceCode: "330100", ProvinceName: "杭州市"}, "service_region_info[0].province_code"},
+		{&RegionInfo{ProvinceCode: "990000", ProvinceName: "未知"}, "service_region_info[0].province_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", AreaCode: "330106"}, "service_region_info[0].city_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "320100", CityName: "南京市"}, "service_region_info[0].city_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "331200", CityName: "未知"}, "service_region_info[0].city_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "330100", CityName: "杭州市", AreaName: "西湖区"}, "service_region_info[0].area_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "330100", CityName: "杭州市", AreaCode: "330106", AreaName: "西湖"}, "service_region_info[0].area_code"},
+		{&RegionInfo{ProvinceCode: "330000", ProvinceName: "浙江省", CityCode: "330100", CityName: "杭州市", AreaCode: "330199", AreaName: "未知"}, "service_region_info[0].area_code"},
+		{&RegionInfo{ProvinceCode: "440000", ProvinceName: "广东省", CityCode: "441900", CityName: "东莞市", AreaCode: "441901", AreaName: "未知"}, "service_region_info[0].area_code"},
+	}
+	for _, tt := range tests {
+		err := ValidateRegionInfo(tt.info)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Field(tt.field) == nil {
+			t.Errorf("ValidateRegionInfo(%+v) got %v, want error for %v", tt.info, err, tt.field)
+		}
+	}
+}
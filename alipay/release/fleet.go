@@ -0,0 +1,253 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// TokenResolver 返回商家小程序授权给第三方应用的app_auth_token
+type TokenResolver func(ctx context.Context, miniAppID string) (string, error)
+
+// ExtFunc 返回商家小程序上传版本时使用的模板配置参数，即UploadVersionBiz.Ext
+type ExtFunc func(ctx context.Context, usage *alipay.TemplateUsageInfo) (string, error)
+
+// FleetOp 批量升级中对单个商家小程序执行的操作
+type FleetOp string
+
+// 批量升级中对单个商家小程序执行的操作
+const (
+	FleetOpResolve FleetOp = "resolve" // 获取app_auth_token
+	FleetOpExt     FleetOp = "ext"     // 生成模板配置参数
+	FleetOpVersion FleetOp = "version" // 计算新版本号
+	FleetOpUpload  FleetOp = "upload"  // 上传版本
+	FleetOpBuild   FleetOp = "build"   // 等待构建
+	FleetOpAudit   FleetOp = "audit"   // 提交审核
+)
+
+// AppResult 单个商家小程序的升级结果
+type AppResult struct {
+	MiniAppID   string  `json:"mini_app_id"`
+	FromVersion string  `json:"from_version"`          // 升级前商家小程序的版本，即TemplateUsageInfo.AppVersion
+	AppVersion  string  `json:"app_version,omitempty"` // 上传的版本，上传前就会记录
+	Uploaded    bool    `json:"uploaded"`              // 是否已上传成功
+	Audited     bool    `json:"audited"`               // 是否已提交审核
+	FailedOp    FleetOp `json:"failed_op,omitempty"`   // 失败的操作
+	Error       string  `json:"error,omitempty"`       // 失败的原因
+}
+
+// OK 是否升级成功
+func (r *AppResult) OK() bool {
+	return r.Error == ""
+}
+
+// Report 批量升级的结果，可以保存为JSON，之后传给Fleet.Retry只重试失败的小程序
+type Report struct {
+	TemplateID      string       `json:"template_id"`
+	TemplateVersion string       `json:"template_version"`
+	Results         []*AppResult `json:"results"` // 按MiniAppID排序
+}
+
+// Failed 返回升级失败的小程序
+func (r *Report) Failed() []*AppResult {
+	var failed []*AppResult
+	for _, result := range r.Results {
+		if !result.OK() {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Succeeded 返回升级成功的小程序
+func (r *Report) Succeeded() []*AppResult {
+	var succeeded []*AppResult
+	for _, result := range r.Results {
+		if result.OK() {
+			succeeded = append(succeeded, result)
+		}
+	}
+	return succeeded
+}
+
+// DefaultConcurrency 默认同时升级的小程序数量
+const DefaultConcurrency = 4
+
+// Fleet 将模板的新版本批量上传到所有使用该模板的商家小程序
+//
+// Run分页查询QueryTemplateUsage得到全部商家小程序，对每个小程序获取app_auth_token、
+// 在已有版本上按Bump递增版本号并上传，Audit不为nil时等待构建完成后提交审核。
+// 单个小程序失败不会影响其它小程序，结果记录在Report中。
+type Fleet struct {
+	Mini alipay.MiniAPI
+
+	TemplateID      string
+	TemplateVersion string // 要升级到的模板版本，为空时使用最新在架模板版本
+	BundleID        alipay.BundleID
+
+	// ResolveToken 获取商家小程序的app_auth_token，必选
+	ResolveToken TokenResolver
+
+	// Ext 生成每个商家小程序的模板配置参数，为nil时不传
+	Ext ExtFunc
+
+	// Bump 商家小程序新版本号的递增位置
+	Bump alipay.VersionBump
+
+	// Audit 提交审核的参数，为nil时只上传不提交审核，AppVersion由Fleet填写
+	Audit *alipay.ApplyVersionAuditBiz
+
+	// Concurrency 同时升级的小程序数量，不大于0时使用DefaultConcurrency
+	Concurrency int
+
+	// PageSize 每页查询的模板使用数量，不大于0时使用最大值50
+	PageSize int
+
+	// OnResult 每个小程序升级结束后调用，可能在多个goroutine中同时调用
+	OnResult func(*AppResult)
+}
+
+// Run 升级所有使用模板的商家小程序
+//
+// 只有查询模板使用情况失败时返回错误，单个小程序的失败记录在Report中。
+func (f *Fleet) Run(ctx context.Context) (*Report, error) {
+	usages, err := f.usages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*AppResult, len(usages))
+	for i, usage := range usages {
+		results[i] = &AppResult{MiniAppID: usage.MiniAppID, FromVersion: usage.AppVersion}
+	}
+	return f.run(ctx, results)
+}
+
+// Retry 只重试prev中失败的小程序，已上传的小程序不会重复上传，返回合并后的完整结果
+//
+// 上传失败的小程序沿用记录的AppVersion，商家小程序已有该版本时认为上次上传成功但响应丢失，不再重复上传。
+func (f *Fleet) Retry(ctx context.Context, prev *Report) (*Report, error) {
+	results := make([]*AppResult, len(prev.Results))
+	for i, result := range prev.Results {
+		copied := *result
+		results[i] = &copied
+	}
+	return f.run(ctx, results)
+}
+
+func (f *Fleet) usages(ctx context.Context) ([]*alipay.TemplateUsageInfo, error) {
+	return alipay.NewTemplateUsageIterator(f.Mini, &alipay.QueryTemplateUsageBiz{
+		TemplateID: f.TemplateID,
+		PageSize:   f.PageSize,
+		BundleID:   f.BundleID,
+	}).All(ctx)
+}
+
+// run 并发升级results中未成功的小程序
+func (f *Fleet) run(ctx context.Context, results []*AppResult) (*Report, error) {
+	if f.ResolveToken == nil {
+		return nil, errors.New("release: Fleet.ResolveToken is nil")
+	}
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, result := range results {
+		if result.OK() && result.Uploaded {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *AppResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result.FailedOp, result.Error = "", ""
+			if op, err := f.upgrade(ctx, result); err != nil {
+				result.FailedOp, result.Error = op, err.Error()
+			}
+			if f.OnResult != nil {
+				f.OnResult(result)
+			}
+		}(result)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].MiniAppID < results[j].MiniAppID })
+	return &Report{TemplateID: f.TemplateID, TemplateVersion: f.TemplateVersion, Results: results}, nil
+}
+
+// upgrade 升级单个小程序，返回失败的操作
+func (f *Fleet) upgrade(ctx context.Context, result *AppResult) (FleetOp, error) {
+	if err := ctx.Err(); err != nil {
+		return FleetOpResolve, err
+	}
+	token, err := f.ResolveToken(ctx, result.MiniAppID)
+	if err != nil {
+		return FleetOpResolve, err
+	}
+	opts := []alipay.ValueOptions{alipay.AppAuthToken(token)}
+
+	if !result.Uploaded {
+		if op, err := f.upload(ctx, result, opts); err != nil {
+			return op, err
+		}
+	}
+
+	if f.Audit == nil || result.Audited {
+		return "", nil
+	}
+	if _, err := alipay.WaitForBuild(ctx, f.Mini, result.AppVersion, opts...); err != nil {
+		return FleetOpBuild, err
+	}
+	audit := *f.Audit
+	audit.AppVersion = result.AppVersion
+	if audit.BundleID == "" {
+		audit.BundleID = f.BundleID
+	}
+	if err := f.Mini.ApplyVersionAudit(ctx, &audit, opts...); err != nil {
+		return FleetOpAudit, err
+	}
+	result.Audited = true
+	return "", nil
+}
+
+// upload 上传新版本，重试时先查询已有版本，避免上次上传的响应丢失后再次递增版本号重复上传
+func (f *Fleet) upload(ctx context.Context, result *AppResult, opts []alipay.ValueOptions) (FleetOp, error) {
+	list, err := f.Mini.QueryVersionList(ctx, opts...)
+	if err != nil {
+		return FleetOpVersion, err
+	}
+	if result.AppVersion == "" {
+		result.AppVersion = list.Next(f.Bump).String()
+	} else {
+		for _, v := range list.AppVersions {
+			if v == result.AppVersion {
+				result.Uploaded = true
+				return "", nil
+			}
+		}
+	}
+	biz := &alipay.UploadVersionBiz{
+		AppVersion:      result.AppVersion,
+		BundleID:        f.BundleID,
+		TemplateID:      f.TemplateID,
+		TemplateVersion: f.TemplateVersion,
+	}
+	if f.Ext != nil {
+		usage := &alipay.TemplateUsageInfo{MiniAppID: result.MiniAppID, AppVersion: result.FromVersion}
+		if biz.Ext, err = f.Ext(ctx, usage); err != nil {
+			return FleetOpExt, err
+		}
+	}
+	if err := f.Mini.UploadVersion(ctx, biz, opts...); err != nil {
+		return FleetOpUpload, err
+	}
+	result.Uploaded = true
+	return "", nil
+}
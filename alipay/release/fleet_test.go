@@ -0,0 +1,161 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+	"github.com/Cluas/go-alipay/alipay/alipayfake"
+	"github.com/Cluas/go-alipay/alipay/alipaytest"
+)
+
+func TestFleet_Run(t *testing.T) {
+	s := alipaytest.NewServer()
+	defer s.Close()
+	sim := alipaytest.NewMiniSimulator(s)
+	apps := []string{"2021000000000003", "2021000000000001", "2021000000000002"}
+	s.HandleFunc("alipay.open.mini.template.usage.query", func(r *alipaytest.Request) (interface{}, error) {
+		var biz alipay.QueryTemplateUsageBiz
+		if err := r.Decode(&biz); err != nil {
+			return nil, err
+		}
+		var list []*alipay.TemplateUsageInfo
+		for i := (biz.PageNum - 1) * biz.PageSize; i < len(apps) && i < biz.PageNum*biz.PageSize; i++ {
+			list = append(list, &alipay.TemplateUsageInfo{MiniAppID: apps[i], AppVersion: "0.0.1"})
+		}
+		return &alipay.QueryTemplateUsageResp{TemplateUsageInfoList: list}, nil
+	})
+	client := s.Client()
+	client.PollBackoff = &alipay.Backoff{Initial: time.Millisecond}
+
+	broken := map[string]bool{"2021000000000002": true}
+	var mu sync.Mutex
+	f := &Fleet{
+		Mini:            client.Mini,
+		TemplateID:      "1",
+		TemplateVersion: "0.0.2",
+		PageSize:        2,
+		Concurrency:     2,
+		ResolveToken: func(ctx context.Context, miniAppID string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if broken[miniAppID] {
+				return "", errors.New("token expired")
+			}
+			return "token-" + miniAppID, nil
+		},
+		Ext: func(ctx context.Context, usage *alipay.TemplateUsageInfo) (string, error) {
+			return fmt.Sprintf(`{"extJson":{"appId":"%v"}}`, usage.MiniAppID), nil
+		},
+		Audit: &alipay.ApplyVersionAuditBiz{VersionDesc: "升级模板版本", RegionType: "CHINA"},
+	}
+
+	report, err := f.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Fleet.Run returned unexcepted error: %v", err)
+	}
+	if len(report.Results) != 3 || report.Results[0].MiniAppID != "2021000000000001" {
+		t.Fatalf("Fleet.Run got %+v, want 3 results sorted by app id", report.Results)
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].MiniAppID != "2021000000000002" || failed[0].FailedOp != FleetOpResolve {
+		t.Errorf("Report.Failed got %+v, want 2021000000000002 failed at resolve", failed)
+	}
+	for _, result := range report.Succeeded() {
+		if result.AppVersion != "0.0.1" || !result.Uploaded || !result.Audited {
+			t.Errorf("result got %+v, want 0.0.1 uploaded and audited", result)
+		}
+		if got := sim.Status("token-"+result.MiniAppID, "0.0.1"); got != alipay.VersionStatusAuditing {
+			t.Errorf("status of %v got %v, want %v", result.MiniAppID, got, alipay.VersionStatusAuditing)
+		}
+	}
+
+	broken = nil
+	var retried []string
+	f.OnResult = func(result *AppResult) { retried = append(retried, result.MiniAppID) }
+	report, err = f.Retry(context.Background(), report)
+	if err != nil {
+		t.Fatalf("Fleet.Retry returned unexcepted error: %v", err)
+	}
+	if len(report.Failed()) != 0 || len(report.Succeeded()) != 3 {
+		t.Errorf("Fleet.Retry got %v failed, want none", report.Failed())
+	}
+	if len(retried) != 1 || retried[0] != "2021000000000002" {
+		t.Errorf("Fleet.Retry retried %v, want only 2021000000000002", retried)
+	}
+}
+
+func TestFleet_Retry_audit(t *testing.T) {
+	auditErr := errors.New("系统繁忙")
+	mini := &alipayfake.Mini{
+		ApplyVersionAuditFunc: func(ctx context.Context, biz *alipay.ApplyVersionAuditBiz, opts ...alipay.ValueOptions) error {
+			return auditErr
+		},
+	}
+	f := &Fleet{
+		Mini:         mini,
+		TemplateID:   "1",
+		ResolveToken: func(ctx context.Context, miniAppID string) (string, error) { return "token", nil },
+		Audit:        &alipay.ApplyVersionAuditBiz{VersionDesc: "升级模板版本"},
+	}
+	prev := &Report{TemplateID: "1", Results: []*AppResult{{MiniAppID: "2021000000000001"}}}
+
+	report, _ := f.Retry(context.Background(), prev)
+	result := report.Results[0]
+	if result.OK() || result.FailedOp != FleetOpAudit || !result.Uploaded || result.AppVersion != "0.0.1" {
+		t.Fatalf("Fleet.Retry got %+v, want uploaded and failed at audit", result)
+	}
+	if prev.Results[0].Uploaded {
+		t.Errorf("Fleet.Retry modified previous report")
+	}
+
+	auditErr = nil
+	report, _ = f.Retry(context.Background(), report)
+	if result := report.Results[0]; !result.OK() || !result.Audited {
+		t.Errorf("Fleet.Retry got %+v, want audited", result)
+	}
+	if got := len(mini.CallsTo("UploadVersion")); got != 1 {
+		t.Errorf("UploadVersion calls got %v, want 1", got)
+	}
+	if got := len(mini.CallsTo("ApplyVersionAudit")); got != 2 {
+		t.Errorf("ApplyVersionAudit calls got %v, want 2", got)
+	}
+}
+
+func TestFleet_Retry_lostUpload(t *testing.T) {
+	var versions []string
+	mini := &alipayfake.Mini{
+		QueryVersionListFunc: func(ctx context.Context, opts ...alipay.ValueOptions) (*alipay.QueryVersionListResp, error) {
+			return &alipay.QueryVersionListResp{AppVersions: versions}, nil
+		},
+		// 上传成功但响应丢失
+		UploadVersionFunc: func(ctx context.Context, biz *alipay.UploadVersionBiz, opts ...alipay.ValueOptions) error {
+			versions = append(versions, biz.AppVersion)
+			return errors.New("connection reset")
+		},
+	}
+	f := &Fleet{
+		Mini:         mini,
+		TemplateID:   "1",
+		ResolveToken: func(ctx context.Context, miniAppID string) (string, error) { return "token", nil },
+	}
+	prev := &Report{TemplateID: "1", Results: []*AppResult{{MiniAppID: "2021000000000001", FromVersion: "0.0.1"}}}
+
+	report, _ := f.Retry(context.Background(), prev)
+	result := report.Results[0]
+	if result.OK() || result.FailedOp != FleetOpUpload || result.Uploaded || result.AppVersion != "0.0.1" {
+		t.Fatalf("Fleet.Retry got %+v, want 0.0.1 failed at upload", result)
+	}
+
+	report, _ = f.Retry(context.Background(), report)
+	if result := report.Results[0]; !result.OK() || !result.Uploaded || result.AppVersion != "0.0.1" {
+		t.Errorf("Fleet.Retry got %+v, want 0.0.1 uploaded", result)
+	}
+	if got := len(mini.CallsTo("UploadVersion")); got != 1 {
+		t.Errorf("UploadVersion calls got %v, want 1", got)
+	}
+}
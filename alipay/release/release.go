@@ -0,0 +1,396 @@
+// Package release 将小程序从上传版本到上架的发布流程建模为可恢复的状态机
+//
+// Pipeline依次执行上传版本、等待构建、生成体验版、提交审核、等待审核结果和上架，
+// 每次状态变化都会保存到Store并发出Event。进程崩溃后使用同一个id再次调用Run，
+// 会从保存的步骤继续执行；ctx被取消时保留当前进度，之后可以继续执行。
+// 调用Cancel才会撤销已提交的审核并将进度标记为已取消。
+//
+// Rollout对审核通过的版本分阶段灰度，每个阶段之间进行健康检查，未通过时撤销灰度或回滚。
+// Fleet供第三方应用将模板的新版本批量上传到所有使用该模板的商家小程序。
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// Step 发布流程的步骤
+type Step string
+
+// 发布流程的步骤
+const (
+	StepUpload     Step = "upload"     // 上传版本
+	StepBuild      Step = "build"      // 等待构建
+	StepExperience Step = "experience" // 生成体验版
+	StepAudit      Step = "audit"      // 提交审核
+	StepWaitAudit  Step = "wait_audit" // 等待审核结果
+	StepOnline     Step = "online"     // 上架
+	StepDone       Step = "done"       // 发布完成
+	StepFailed     Step = "failed"     // 发布失败
+	StepCanceled   Step = "canceled"   // 发布已取消
+)
+
+// IsTerminal 流程是否已经结束
+func (s Step) IsTerminal() bool {
+	return s == StepDone || s == StepFailed || s == StepCanceled
+}
+
+// State 持久化的发布进度
+type State struct {
+	ID           string    `json:"id"`
+	AppVersion   string    `json:"app_version"`
+	Step         Step      `json:"step"`
+	ExpQrCodeURL string    `json:"exp_qr_code_url,omitempty"` // 体验版二维码地址
+	RejectReason string    `json:"reject_reason,omitempty"`   // 审核驳回原因
+	Error        string    `json:"error,omitempty"`           // 失败或取消的原因
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Event 发布流程的一次状态变化
+type Event struct {
+	ID         string
+	AppVersion string
+	From       Step
+	To         Step
+	Err        error // 进入StepFailed或StepCanceled的原因
+	Time       time.Time
+}
+
+// RejectedError 审核被驳回
+type RejectedError struct {
+	AppVersion string
+	Reason     string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("release: audit of version %v rejected: %v", e.AppVersion, e.Reason)
+}
+
+// StatusError 版本处于流程无法继续的状态，例如审核被外部撤销或版本被冻结
+type StatusError struct {
+	AppVersion string
+	Status     alipay.VersionStatus
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("release: version %v is %v (%v)", e.AppVersion, e.Status, string(e.Status))
+}
+
+// DefaultAuditBackoff 默认的审核结果轮询间隔
+var DefaultAuditBackoff = alipay.Backoff{Initial: 30 * time.Second, Max: 5 * time.Minute, Multiplier: 2}
+
+// Pipeline 小程序发布流程
+type Pipeline struct {
+	Mini  alipay.MiniAPI
+	Store Store
+
+	// Upload 上传版本的参数，AppVersion为空时在已有版本上按Bump递增
+	Upload *alipay.UploadVersionBiz
+	Bump   alipay.VersionBump
+
+	// Experience 为true时在构建完成后生成体验版并等待打包完成
+	Experience bool
+
+	// Audit 提交审核的参数，AppVersion由流程填写
+	Audit *alipay.ApplyVersionAuditBiz
+
+	// SkipOnline 为true时审核通过后停在待上架，由灰度发布等其它流程上架
+	SkipOnline bool
+
+	// AuditBackoff 轮询审核结果的间隔，为nil时使用DefaultAuditBackoff
+	AuditBackoff *alipay.Backoff
+
+	// Options 每个请求附加的参数，例如第三方应用的alipay.AppAuthToken
+	Options []alipay.ValueOptions
+
+	// OnEvent 每次状态变化后调用
+	OnEvent func(Event)
+
+	// Clock 返回当前时间，为nil时使用time.Now
+	Clock func() time.Time
+}
+
+// Run 执行或继续id对应的发布流程，直到完成、失败或ctx被取消
+//
+// 已经结束的流程直接返回保存的进度。网络错误等临时错误和ctx被取消不会改变进度，
+// 返回错误后可以使用同一个id重试；构建失败、审核驳回等错误会将流程标记为失败。
+func (p *Pipeline) Run(ctx context.Context, id string) (*State, error) {
+	state, err := p.Store.Load(ctx, id)
+	if err == ErrNotFound {
+		now := p.now()
+		state = &State{ID: id, Step: StepUpload, CreatedAt: now, UpdatedAt: now}
+		if p.Upload != nil {
+			state.AppVersion = p.Upload.AppVersion
+		}
+		if err := p.Store.Save(ctx, state); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	for !state.Step.IsTerminal() {
+		next, err := p.step(ctx, state)
+		if err != nil {
+			if ctx.Err() != nil {
+				return state, ctx.Err()
+			}
+			if isPermanent(err) {
+				if err := p.transition(state, StepFailed, err); err != nil {
+					return state, err
+				}
+			}
+			return state, err
+		}
+		if err := p.transition(state, next, nil); err != nil {
+			return state, err
+		}
+	}
+	return state, stateError(state)
+}
+
+func stateError(state *State) error {
+	if state.Step == StepFailed || state.Step == StepCanceled {
+		return fmt.Errorf("release: %v %v: %v", state.ID, state.Step, state.Error)
+	}
+	return nil
+}
+
+// isPermanent 重试也无法成功的错误
+func isPermanent(err error) bool {
+	var (
+		buildErr      *alipay.BuildError
+		experienceErr *alipay.ExperienceError
+		validationErr *alipay.ValidationError
+		rejectedErr   *RejectedError
+		statusErr     *StatusError
+	)
+	return errors.As(err, &buildErr) || errors.As(err, &experienceErr) || errors.As(err, &validationErr) ||
+		errors.As(err, &rejectedErr) || errors.As(err, &statusErr)
+}
+
+func (p *Pipeline) now() time.Time {
+	if p.Clock != nil {
+		return p.Clock()
+	}
+	return time.Now()
+}
+
+// transition 保存新的步骤并发出事件，ctx可能已被取消，因此使用独立的context保存
+func (p *Pipeline) transition(state *State, to Step, cause error) error {
+	from := state.Step
+	state.Step = to
+	state.UpdatedAt = p.now()
+	if cause != nil {
+		state.Error = cause.Error()
+	}
+	if err := p.Store.Save(context.Background(), state); err != nil {
+		return err
+	}
+	if p.OnEvent != nil {
+		p.OnEvent(Event{ID: state.ID, AppVersion: state.AppVersion, From: from, To: to, Err: cause, Time: state.UpdatedAt})
+	}
+	return nil
+}
+
+// ErrCanceled 调用Cancel取消发布流程的原因
+var ErrCanceled = errors.New("release: canceled")
+
+// Cancel 取消id对应的发布流程，撤销可能已提交的审核并将进度标记为StepCanceled
+//
+// 已经结束的流程直接返回保存的进度。撤销审核失败时进度不变，可以再次调用Cancel。
+// 正在执行的Run需要先取消其ctx。
+func (p *Pipeline) Cancel(ctx context.Context, id string) (*State, error) {
+	state, err := p.Store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state.Step.IsTerminal() {
+		return state, nil
+	}
+	// 提交审核的步骤可能在审核提交成功后、保存进度前中断
+	if state.Step == StepAudit || state.Step == StepWaitAudit {
+		if err := p.cancelAudit(ctx, state); err != nil {
+			return state, err
+		}
+	}
+	if err := p.transition(state, StepCanceled, ErrCanceled); err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+// cancelAudit 撤销审核，版本不在审核中时忽略撤销失败
+func (p *Pipeline) cancelAudit(ctx context.Context, state *State) error {
+	err := p.Mini.CancelVersionAudit(ctx, &alipay.CancelVersionAuditBiz{
+		AppVersion: state.AppVersion,
+		BundleID:   p.bundleID(),
+	}, p.Options...)
+	var errResp *alipay.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return err
+	}
+	detail, detailErr := p.detail(ctx, state)
+	if detailErr != nil || detail.Status == alipay.VersionStatusAuditing {
+		return fmt.Errorf("release: cancel audit of version %v: %w", state.AppVersion, err)
+	}
+	return nil
+}
+
+func (p *Pipeline) bundleID() alipay.BundleID {
+	if p.Upload != nil {
+		return p.Upload.BundleID
+	}
+	return ""
+}
+
+// step 执行当前步骤，返回下一个步骤
+func (p *Pipeline) step(ctx context.Context, state *State) (Step, error) {
+	switch state.Step {
+	case StepUpload:
+		return p.upload(ctx, state)
+	case StepBuild:
+		if _, err := alipay.WaitForBuild(ctx, p.Mini, state.AppVersion, p.Options...); err != nil {
+			return "", err
+		}
+		if p.Experience {
+			return StepExperience, nil
+		}
+		return StepAudit, nil
+	case StepExperience:
+		status, err := alipay.CreateExperienceAndWait(ctx, p.Mini, &alipay.CreateExperienceBiz{
+			AppVersion: state.AppVersion,
+			BundleID:   p.bundleID(),
+		}, p.Options...)
+		if err != nil {
+			return "", err
+		}
+		state.ExpQrCodeURL = status.ExpQrCodeURL
+		return StepAudit, nil
+	case StepAudit:
+		return p.applyAudit(ctx, state)
+	case StepWaitAudit:
+		return p.waitAudit(ctx, state)
+	case StepOnline:
+		return p.online(ctx, state)
+	}
+	return "", fmt.Errorf("release: unknown step %q", state.Step)
+}
+
+func (p *Pipeline) upload(ctx context.Context, state *State) (Step, error) {
+	if p.Upload == nil {
+		return "", errors.New("release: Pipeline.Upload is nil")
+	}
+	list, err := p.Mini.QueryVersionList(ctx, p.Options...)
+	if err != nil {
+		return "", err
+	}
+	if state.AppVersion == "" {
+		state.AppVersion = list.Next(p.Bump).String()
+		if err := p.Store.Save(ctx, state); err != nil {
+			return "", err
+		}
+	}
+	// 上次运行可能在上传成功后、保存进度前崩溃，版本已存在时直接等待构建
+	for _, v := range list.AppVersions {
+		if v == state.AppVersion {
+			return StepBuild, nil
+		}
+	}
+	biz := *p.Upload
+	biz.AppVersion = state.AppVersion
+	if err := p.Mini.UploadVersion(ctx, &biz, p.Options...); err != nil {
+		return "", err
+	}
+	return StepBuild, nil
+}
+
+func (p *Pipeline) detail(ctx context.Context, state *State) (*alipay.VersionDetail, error) {
+	return p.Mini.QueryVersionDetail(ctx, &alipay.QueryVersionDetailBiz{
+		AppVersion: state.AppVersion,
+		BundleID:   p.bundleID(),
+	}, p.Options...)
+}
+
+func (p *Pipeline) applyAudit(ctx context.Context, state *State) (Step, error) {
+	if p.Audit == nil {
+		return "", errors.New("release: Pipeline.Audit is nil")
+	}
+	detail, err := p.detail(ctx, state)
+	if err != nil {
+		return "", err
+	}
+	// 上次运行可能已经提交了审核
+	if detail.Status != alipay.VersionStatusInit && detail.Status != alipay.VersionStatusAuditReject {
+		return StepWaitAudit, nil
+	}
+	biz := *p.Audit
+	biz.AppVersion = state.AppVersion
+	if biz.BundleID == "" {
+		biz.BundleID = p.bundleID()
+	}
+	if err := p.Mini.ApplyVersionAudit(ctx, &biz, p.Options...); err != nil {
+		return "", err
+	}
+	return StepWaitAudit, nil
+}
+
+func (p *Pipeline) waitAudit(ctx context.Context, state *State) (Step, error) {
+	b := DefaultAuditBackoff
+	if p.AuditBackoff != nil {
+		b = *p.AuditBackoff
+	}
+	var wait time.Duration
+	for {
+		detail, err := p.detail(ctx, state)
+		if err != nil {
+			return "", err
+		}
+		switch detail.Status {
+		case alipay.VersionStatusAuditing:
+		case alipay.VersionStatusWaitRelease, alipay.VersionStatusBaseAuditPass:
+			if p.SkipOnline {
+				return StepDone, nil
+			}
+			return StepOnline, nil
+		case alipay.VersionStatusGray, alipay.VersionStatusRelease:
+			return StepDone, nil
+		case alipay.VersionStatusAuditReject:
+			state.RejectReason = detail.RejectReason
+			return "", &RejectedError{AppVersion: state.AppVersion, Reason: detail.RejectReason}
+		default:
+			return "", &StatusError{AppVersion: state.AppVersion, Status: detail.Status}
+		}
+		wait = b.Next(wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *Pipeline) online(ctx context.Context, state *State) (Step, error) {
+	detail, err := p.detail(ctx, state)
+	if err != nil {
+		return "", err
+	}
+	if detail.Status == alipay.VersionStatusRelease {
+		return StepDone, nil
+	}
+	err = p.Mini.OnlineVersion(ctx, &alipay.OnlineVersionBiz{
+		AppVersion: state.AppVersion,
+		BundleID:   p.bundleID(),
+	}, p.Options...)
+	if err != nil {
+		return "", err
+	}
+	return StepDone, nil
+}
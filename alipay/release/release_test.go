@@ -0,0 +1,234 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+	"github.com/Cluas/go-alipay/alipay/alipaytest"
+)
+
+func setup() (*Pipeline, *alipaytest.MiniSimulator, *alipay.Client, func()) {
+	s := alipaytest.NewServer()
+	sim := alipaytest.NewMiniSimulator(s)
+	client := s.Client()
+	client.PollBackoff = &alipay.Backoff{Initial: time.Millisecond}
+	p := &Pipeline{
+		Mini:   client.Mini,
+		Store:  NewMemoryStore(),
+		Upload: &alipay.UploadVersionBiz{TemplateID: "1"},
+		Audit: &alipay.ApplyVersionAuditBiz{
+			VersionDesc:     "小程序版本描述小程序版本描述",
+			RegionType:      "CHINA",
+			FirstScreenShot: alipay.FileFromBytes("1.png", []byte("1")),
+		},
+		AuditBackoff: &alipay.Backoff{Initial: time.Millisecond},
+	}
+	return p, sim, client, s.Close
+}
+
+func TestPipeline_Run(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	p.Experience = true
+	var steps []Step
+	p.OnEvent = func(e Event) { steps = append(steps, e.To) }
+
+	state, err := p.Run(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("Run returned unexcepted error: %v", err)
+	}
+	if state.Step != StepDone || state.AppVersion != "0.0.1" || state.ExpQrCodeURL == "" {
+		t.Errorf("Run got %+v, want done 0.0.1 with qr code", state)
+	}
+	want := []Step{StepBuild, StepExperience, StepAudit, StepWaitAudit, StepOnline, StepDone}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("events got %v, want %v", steps, want)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusRelease {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusRelease)
+	}
+
+	saved, err := p.Store.Load(context.Background(), "job")
+	if err != nil || saved.Step != StepDone {
+		t.Errorf("Store.Load got %+v, %v, want done", saved, err)
+	}
+	steps = nil
+	if _, err := p.Run(context.Background(), "job"); err != nil || steps != nil {
+		t.Errorf("Run of finished job got %v, events %v", err, steps)
+	}
+}
+
+func TestPipeline_Run_resume(t *testing.T) {
+	p, sim, client, tearDown := setup()
+	defer tearDown()
+	ctx := context.Background()
+
+	// 模拟上次运行在上传成功后、保存进度前崩溃
+	if err := client.Mini.UploadVersion(ctx, &alipay.UploadVersionBiz{AppVersion: "0.0.2", TemplateID: "1"}); err != nil {
+		t.Fatalf("Mini.UploadVersion returned unexcepted error: %v", err)
+	}
+	p.Store.Save(ctx, &State{ID: "job", AppVersion: "0.0.2", Step: StepUpload})
+
+	state, err := p.Run(ctx, "job")
+	if err != nil {
+		t.Fatalf("Run returned unexcepted error: %v", err)
+	}
+	if state.Step != StepDone || state.AppVersion != "0.0.2" {
+		t.Errorf("Run got %+v, want done 0.0.2", state)
+	}
+	if got := sim.Status("", "0.0.2"); got != alipay.VersionStatusRelease {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusRelease)
+	}
+}
+
+func TestPipeline_Run_rejected(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	p.Upload.AppVersion = "1.0.0"
+	p.SkipOnline = true
+	sim.RejectAudit("1.0.0", "内容违规")
+
+	state, err := p.Run(context.Background(), "job")
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != "内容违规" {
+		t.Fatalf("Run got error %v, want RejectedError", err)
+	}
+	if state.Step != StepFailed || state.RejectReason != "内容违规" || state.Error == "" {
+		t.Errorf("Run got %+v, want failed with reject reason", state)
+	}
+	if _, err := p.Run(context.Background(), "job"); err == nil {
+		t.Errorf("Run of failed job excepted error")
+	}
+}
+
+func TestPipeline_Run_buildFailed(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	sim.FailBuild("0.0.1")
+
+	state, err := p.Run(context.Background(), "job")
+	var buildErr *alipay.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Fatalf("Run got error %v, want BuildError", err)
+	}
+	if state.Step != StepFailed {
+		t.Errorf("Run step got %v, want %v", state.Step, StepFailed)
+	}
+}
+
+func TestPipeline_Run_interrupted(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	sim.AuditPolls = -1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.OnEvent = func(e Event) {
+		if e.To == StepWaitAudit {
+			cancel()
+		}
+	}
+
+	state, err := p.Run(ctx, "job")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run got error %v, want context.Canceled", err)
+	}
+	if state.Step != StepWaitAudit {
+		t.Errorf("Run step got %v, want %v", state.Step, StepWaitAudit)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusAuditing {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusAuditing)
+	}
+
+	// ctx被取消后可以继续执行
+	p.OnEvent = nil
+	sim.ApproveAudit("0.0.1")
+	state, err = p.Run(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("Run returned unexcepted error: %v", err)
+	}
+	if state.Step != StepDone {
+		t.Errorf("Run step got %v, want %v", state.Step, StepDone)
+	}
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	sim.AuditPolls = -1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.OnEvent = func(e Event) {
+		if e.To == StepWaitAudit {
+			cancel()
+		}
+	}
+	if _, err := p.Run(ctx, "job"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run got error %v, want context.Canceled", err)
+	}
+
+	var last Event
+	p.OnEvent = func(e Event) { last = e }
+	state, err := p.Cancel(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("Cancel returned unexcepted error: %v", err)
+	}
+	if state.Step != StepCanceled || last.To != StepCanceled || last.Err != ErrCanceled {
+		t.Errorf("Cancel got %+v, last event %+v, want canceled", state, last)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusInit {
+		t.Errorf("status got %v, want audit canceled to %v", got, alipay.VersionStatusInit)
+	}
+	if _, err := p.Run(context.Background(), "job"); err == nil {
+		t.Errorf("Run excepted error for canceled release")
+	}
+}
+
+func TestPipeline_Cancel_audit(t *testing.T) {
+	p, sim, _, tearDown := setup()
+	defer tearDown()
+	sim.AuditPolls = -1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.OnEvent = func(e Event) {
+		if e.To == StepAudit {
+			cancel()
+		}
+	}
+	if _, err := p.Run(ctx, "job"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run got error %v, want context.Canceled", err)
+	}
+
+	// 审核还没有提交，撤销审核失败时忽略
+	state, err := p.Cancel(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("Cancel returned unexcepted error: %v", err)
+	}
+	if state.Step != StepCanceled {
+		t.Errorf("Cancel step got %v, want %v", state.Step, StepCanceled)
+	}
+
+	// 审核已经提交但进度停在提交审核
+	biz := *p.Audit
+	biz.AppVersion = state.AppVersion
+	if err := p.Mini.ApplyVersionAudit(context.Background(), &biz); err != nil {
+		t.Fatalf("ApplyVersionAudit returned unexcepted error: %v", err)
+	}
+	state.ID, state.Step = "job2", StepAudit
+	if err := p.Store.Save(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	state, err = p.Cancel(context.Background(), "job2")
+	if err != nil {
+		t.Fatalf("Cancel returned unexcepted error: %v", err)
+	}
+	if state.Step != StepCanceled {
+		t.Errorf("Cancel step got %v, want %v", state.Step, StepCanceled)
+	}
+	if got := sim.Status("", state.AppVersion); got != alipay.VersionStatusInit {
+		t.Errorf("status got %v, want audit canceled to %v", got, alipay.VersionStatusInit)
+	}
+}
@@ -0,0 +1,240 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+// HealthCheck 检查灰度中或刚上架的版本是否健康，返回错误时停止灰度并回退
+type HealthCheck func(ctx context.Context, appVersion string, strategy alipay.GrayStrategy) error
+
+// Stage 灰度阶段，灰度到Strategy后等待Dwell再进行健康检查
+type Stage struct {
+	Strategy alipay.GrayStrategy
+	Dwell    time.Duration
+}
+
+// DefaultStages 默认的灰度阶段，依次灰度10%、30%、50%的用户，每个阶段观察一小时
+var DefaultStages = []Stage{
+	{Strategy: alipay.GrayStrategyP10, Dwell: time.Hour},
+	{Strategy: alipay.GrayStrategyP30, Dwell: time.Hour},
+	{Strategy: alipay.GrayStrategyP50, Dwell: time.Hour},
+}
+
+// Action 灰度发布执行的操作
+type Action string
+
+// 灰度发布执行的操作
+const (
+	ActionGray        Action = "gray"         // 调用OnlineGrayVersion
+	ActionHealthCheck Action = "health_check" // 调用HealthCheck
+	ActionOnline      Action = "online"       // 调用OnlineVersion
+	ActionCancelGray  Action = "cancel_gray"  // 调用CancelGrayVersion
+	ActionRollback    Action = "rollback"     // 调用RollbackVersion
+)
+
+// AuditEntry 灰度发布的一条操作记录
+type AuditEntry struct {
+	Time     time.Time           `json:"time"`
+	Action   Action              `json:"action"`
+	Strategy alipay.GrayStrategy `json:"strategy,omitempty"` // 操作时的灰度策略，全量上架后为空
+	Reason   string              `json:"reason"`             // 执行该操作的原因
+	Error    string              `json:"error,omitempty"`    // 操作失败的原因
+}
+
+// RolloutResult 灰度发布的结果
+type RolloutResult struct {
+	AppVersion string
+	Online     bool         // 是否已全量上架
+	Reverted   bool         // 是否已撤销灰度或回滚
+	Trail      []AuditEntry // 按时间顺序的全部操作记录
+}
+
+// HealthError 健康检查未通过
+type HealthError struct {
+	AppVersion string
+	Strategy   alipay.GrayStrategy // 全量上架后检查失败时为空
+	Err        error
+}
+
+func (e *HealthError) Error() string {
+	stage := "online"
+	if e.Strategy != "" {
+		stage = string(e.Strategy)
+	}
+	return fmt.Sprintf("release: health check of version %v failed at %v: %v", e.AppVersion, stage, e.Err)
+}
+
+func (e *HealthError) Unwrap() error { return e.Err }
+
+// Rollout 小程序灰度发布控制器
+//
+// 版本需要处于待上架状态。Run依次灰度到Stages中的每个阶段，等待Dwell后调用Check，
+// 全部通过后全量上架。灰度中检查失败或出错时调用CancelGrayVersion撤销灰度；
+// 上架后检查失败时调用RollbackVersion回滚到上一个线上版本。
+type Rollout struct {
+	Mini     alipay.MiniAPI
+	BundleID alipay.BundleID
+
+	// Stages 灰度阶段，为nil时使用DefaultStages
+	Stages []Stage
+
+	// Check 健康检查，为nil时只等待Dwell
+	Check HealthCheck
+
+	// OnlineDwell 全量上架后等待多久进行最后一次健康检查，为0时不检查
+	OnlineDwell time.Duration
+
+	// Options 每个请求附加的参数，例如第三方应用的alipay.AppAuthToken
+	Options []alipay.ValueOptions
+
+	// OnAudit 每条操作记录产生后调用
+	OnAudit func(AuditEntry)
+
+	// Clock 返回当前时间，为nil时使用time.Now
+	Clock func() time.Time
+}
+
+type rolloutRun struct {
+	*Rollout
+	result *RolloutResult
+}
+
+// Run 对appVersion执行灰度发布，健康检查未通过时返回HealthError
+//
+// 无论成功与否都会返回RolloutResult，其中记录了执行过的全部操作。
+// ctx被取消时同样会撤销灰度，已全量上架的版本不会回滚。
+func (r *Rollout) Run(ctx context.Context, appVersion string) (*RolloutResult, error) {
+	run := &rolloutRun{Rollout: r, result: &RolloutResult{AppVersion: appVersion}}
+	stages := r.Stages
+	if stages == nil {
+		stages = DefaultStages
+	}
+
+	var current alipay.GrayStrategy
+	for _, stage := range stages {
+		reason := fmt.Sprintf("start stage %v", stage.Strategy)
+		if current != "" {
+			reason = fmt.Sprintf("stage %v healthy, expand to %v", current, stage.Strategy)
+		}
+		err := run.record(ActionGray, stage.Strategy, reason, r.Mini.OnlineGrayVersion(ctx, &alipay.OnlineGrayVersionBiz{
+			AppVersion:   appVersion,
+			GrayStrategy: stage.Strategy,
+			BundleID:     r.BundleID,
+		}, r.Options...))
+		if err != nil {
+			return run.cancelGray(current, err)
+		}
+		current = stage.Strategy
+		if err := run.check(ctx, current, stage.Dwell); err != nil {
+			return run.cancelGray(current, err)
+		}
+	}
+
+	reason := "no gray stages"
+	if current != "" {
+		reason = fmt.Sprintf("stage %v healthy, release to all users", current)
+	}
+	err := run.record(ActionOnline, current, reason, r.Mini.OnlineVersion(ctx, &alipay.OnlineVersionBiz{
+		AppVersion: appVersion,
+		BundleID:   r.BundleID,
+	}, r.Options...))
+	if err != nil {
+		return run.cancelGray(current, err)
+	}
+	run.result.Online = true
+
+	if r.OnlineDwell > 0 {
+		if err := run.check(ctx, "", r.OnlineDwell); err != nil {
+			if ctx.Err() != nil {
+				return run.result, err
+			}
+			return run.rollback(err)
+		}
+	}
+	return run.result, nil
+}
+
+func (r *Rollout) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+// record 记录一次操作并返回err
+func (run *rolloutRun) record(action Action, strategy alipay.GrayStrategy, reason string, err error) error {
+	entry := AuditEntry{Time: run.now(), Action: action, Strategy: strategy, Reason: reason}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	run.result.Trail = append(run.result.Trail, entry)
+	if run.OnAudit != nil {
+		run.OnAudit(entry)
+	}
+	return err
+}
+
+// check 等待dwell后进行健康检查
+func (run *rolloutRun) check(ctx context.Context, strategy alipay.GrayStrategy, dwell time.Duration) error {
+	timer := time.NewTimer(dwell)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+	}
+	if run.Check == nil {
+		return nil
+	}
+	reason := fmt.Sprintf("dwell %v at %v", dwell, strategy)
+	if strategy == "" {
+		reason = fmt.Sprintf("dwell %v after online", dwell)
+	}
+	if err := run.record(ActionHealthCheck, strategy, reason, run.Check(ctx, run.result.AppVersion, strategy)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &HealthError{AppVersion: run.result.AppVersion, Strategy: strategy, Err: err}
+	}
+	return nil
+}
+
+// cleanupTimeout 撤销灰度或回滚的超时时间，ctx可能已被取消，因此使用独立的context
+const cleanupTimeout = 30 * time.Second
+
+// cancelGray 撤销灰度，strategy为空说明灰度尚未开始
+func (run *rolloutRun) cancelGray(strategy alipay.GrayStrategy, cause error) (*RolloutResult, error) {
+	if strategy == "" {
+		return run.result, cause
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	err := run.record(ActionCancelGray, strategy, cause.Error(), run.Mini.CancelGrayVersion(ctx, &alipay.CancelGrayVersionBiz{
+		AppVersion: run.result.AppVersion,
+		BundleID:   run.BundleID,
+	}, run.Options...))
+	if err != nil {
+		return run.result, fmt.Errorf("%w; cancel gray: %v", cause, err)
+	}
+	run.result.Reverted = true
+	return run.result, cause
+}
+
+// rollback 回滚到上一个线上版本
+func (run *rolloutRun) rollback(cause error) (*RolloutResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	err := run.record(ActionRollback, "", cause.Error(), run.Mini.RollbackVersion(ctx, &alipay.RollbackVersionBiz{
+		AppVersion: run.result.AppVersion,
+		BundleID:   run.BundleID,
+	}, run.Options...))
+	if err != nil {
+		return run.result, fmt.Errorf("%w; rollback: %v", cause, err)
+	}
+	run.result.Reverted = true
+	return run.result, cause
+}
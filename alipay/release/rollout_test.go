@@ -0,0 +1,157 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Cluas/go-alipay/alipay"
+)
+
+var testStages = []Stage{
+	{Strategy: alipay.GrayStrategyP10, Dwell: time.Millisecond},
+	{Strategy: alipay.GrayStrategyP30, Dwell: time.Millisecond},
+	{Strategy: alipay.GrayStrategyP50, Dwell: time.Millisecond},
+}
+
+// waitRelease 将appVersion发布到待上架状态
+func waitRelease(t *testing.T, p *Pipeline, appVersion string) {
+	t.Helper()
+	p.Upload.AppVersion = appVersion
+	p.SkipOnline = true
+	if _, err := p.Run(context.Background(), appVersion); err != nil {
+		t.Fatalf("Run returned unexcepted error: %v", err)
+	}
+}
+
+func actions(trail []AuditEntry) []Action {
+	var got []Action
+	for _, e := range trail {
+		got = append(got, e.Action)
+	}
+	return got
+}
+
+func TestRollout_Run(t *testing.T) {
+	p, sim, client, tearDown := setup()
+	defer tearDown()
+	waitRelease(t, p, "0.0.1")
+
+	var checked []alipay.GrayStrategy
+	r := &Rollout{
+		Mini:   client.Mini,
+		Stages: testStages,
+		Check: func(ctx context.Context, appVersion string, strategy alipay.GrayStrategy) error {
+			if got := sim.Status("", appVersion); got != alipay.VersionStatusGray {
+				t.Errorf("status at %v got %v, want %v", strategy, got, alipay.VersionStatusGray)
+			}
+			checked = append(checked, strategy)
+			return nil
+		},
+	}
+	result, err := r.Run(context.Background(), "0.0.1")
+	if err != nil {
+		t.Fatalf("Rollout.Run returned unexcepted error: %v", err)
+	}
+	if !result.Online || result.Reverted {
+		t.Errorf("Rollout.Run got %+v, want online", result)
+	}
+	if want := []alipay.GrayStrategy{"p10", "p30", "p50"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked got %v, want %v", checked, want)
+	}
+	want := []Action{ActionGray, ActionHealthCheck, ActionGray, ActionHealthCheck, ActionGray, ActionHealthCheck, ActionOnline}
+	if got := actions(result.Trail); !reflect.DeepEqual(got, want) {
+		t.Errorf("trail got %v, want %v", got, want)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusRelease {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusRelease)
+	}
+}
+
+func TestRollout_Run_cancelGray(t *testing.T) {
+	p, sim, client, tearDown := setup()
+	defer tearDown()
+	waitRelease(t, p, "0.0.1")
+
+	unhealthy := errors.New("crash rate 5%")
+	var trail []AuditEntry
+	r := &Rollout{
+		Mini:   client.Mini,
+		Stages: testStages,
+		Check: func(ctx context.Context, appVersion string, strategy alipay.GrayStrategy) error {
+			if strategy == alipay.GrayStrategyP30 {
+				return unhealthy
+			}
+			return nil
+		},
+		OnAudit: func(e AuditEntry) { trail = append(trail, e) },
+	}
+	result, err := r.Run(context.Background(), "0.0.1")
+	var healthErr *HealthError
+	if !errors.As(err, &healthErr) || healthErr.Strategy != alipay.GrayStrategyP30 || !errors.Is(err, unhealthy) {
+		t.Fatalf("Rollout.Run got error %v, want HealthError at p30", err)
+	}
+	if result.Online || !result.Reverted || !reflect.DeepEqual(result.Trail, trail) {
+		t.Errorf("Rollout.Run got %+v, want reverted", result)
+	}
+	last := result.Trail[len(result.Trail)-1]
+	if last.Action != ActionCancelGray || last.Strategy != alipay.GrayStrategyP30 || last.Reason != err.Error() {
+		t.Errorf("last entry got %+v, want cancel_gray at p30", last)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusWaitRelease {
+		t.Errorf("status got %v, want %v", got, alipay.VersionStatusWaitRelease)
+	}
+}
+
+func TestRollout_Run_rollback(t *testing.T) {
+	p, sim, client, tearDown := setup()
+	defer tearDown()
+	waitRelease(t, p, "0.0.1")
+	if err := client.Mini.OnlineVersion(context.Background(), &alipay.OnlineVersionBiz{AppVersion: "0.0.1"}); err != nil {
+		t.Fatalf("Mini.OnlineVersion returned unexcepted error: %v", err)
+	}
+	waitRelease(t, p, "0.0.2")
+
+	r := &Rollout{
+		Mini:        client.Mini,
+		Stages:      testStages[:1],
+		OnlineDwell: time.Millisecond,
+		Check: func(ctx context.Context, appVersion string, strategy alipay.GrayStrategy) error {
+			if strategy == "" {
+				return errors.New("crash rate 5%")
+			}
+			return nil
+		},
+	}
+	result, err := r.Run(context.Background(), "0.0.2")
+	var healthErr *HealthError
+	if !errors.As(err, &healthErr) || healthErr.Strategy != "" {
+		t.Fatalf("Rollout.Run got error %v, want HealthError after online", err)
+	}
+	want := []Action{ActionGray, ActionHealthCheck, ActionOnline, ActionHealthCheck, ActionRollback}
+	if got := actions(result.Trail); !reflect.DeepEqual(got, want) {
+		t.Errorf("trail got %v, want %v", got, want)
+	}
+	if !result.Online || !result.Reverted {
+		t.Errorf("Rollout.Run got %+v, want online and reverted", result)
+	}
+	if got := sim.Status("", "0.0.1"); got != alipay.VersionStatusRelease {
+		t.Errorf("status of 0.0.1 got %v, want %v", got, alipay.VersionStatusRelease)
+	}
+}
+
+func TestRollout_Run_grayFailed(t *testing.T) {
+	_, _, client, tearDown := setup()
+	defer tearDown()
+
+	r := &Rollout{Mini: client.Mini, Stages: testStages}
+	result, err := r.Run(context.Background(), "0.0.1")
+	if err == nil {
+		t.Fatalf("Rollout.Run excepted error")
+	}
+	if len(result.Trail) != 1 || result.Trail[0].Error == "" || result.Reverted {
+		t.Errorf("Rollout.Run trail got %+v, want one failed gray entry", result.Trail)
+	}
+}
@@ -0,0 +1,115 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound Store中没有对应的发布进度
+var ErrNotFound = errors.New("release: state not found")
+
+// Store 发布进度的持久化存储，Pipeline在每次状态变化后调用Save
+type Store interface {
+	// Load 读取发布进度，不存在时返回ErrNotFound
+	Load(ctx context.Context, id string) (*State, error)
+	// Save 保存发布进度
+	Save(ctx context.Context, state *State) error
+}
+
+// MemoryStore 内存中的Store，进程退出后进度丢失，主要用于测试
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+// NewMemoryStore 创建MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string][]byte)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, id string) (*State, error) {
+	s.mu.Lock()
+	data, ok := s.states[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	state := new(State)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.states[state.ID] = data
+	s.mu.Unlock()
+	return nil
+}
+
+// FileStore 将每个发布进度保存为Dir目录下的<id>.json文件
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore 创建FileStore，目录不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id)+".json")
+}
+
+// Load implements Store.
+func (s *FileStore) Load(ctx context.Context, id string) (*State, error) {
+	data, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := new(State)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save implements Store. 先写临时文件再重命名，进程崩溃时不会留下写了一半的文件
+func (s *FileStore) Save(ctx context.Context, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(s.Dir, filepath.Base(state.ID)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(state.ID))
+}
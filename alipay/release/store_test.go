@@ -0,0 +1,53 @@
+package release
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.Load(ctx, "job"); err != ErrNotFound {
+		t.Errorf("Load got error %v, want ErrNotFound", err)
+	}
+	want := &State{ID: "job", AppVersion: "0.0.1", Step: StepBuild, UpdatedAt: time.Date(2020, 4, 19, 22, 41, 12, 0, time.UTC)}
+	if err := s.Save(ctx, want); err != nil {
+		t.Fatalf("Save returned unexcepted error: %v", err)
+	}
+	want.Step = StepAudit
+	if err := s.Save(ctx, want); err != nil {
+		t.Fatalf("Save returned unexcepted error: %v", err)
+	}
+	got, err := s.Load(ctx, "job")
+	if err != nil {
+		t.Fatalf("Load returned unexcepted error: %v", err)
+	}
+	if got == want || got.Step != StepAudit || got.AppVersion != "0.0.1" || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("Load got %+v, want copy of %+v", got, want)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore returned unexcepted error: %v", err)
+	}
+	testStore(t, s)
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != "job.json" {
+		t.Errorf("FileStore left files %v, want only job.json", files)
+	}
+}
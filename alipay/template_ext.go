@@ -0,0 +1,219 @@
+package alipay
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// TemplateExt 模板小程序的ext.json配置，序列化后作为UploadVersionBiz.Ext上传
+//
+//	ext := alipay.NewTemplateExt().
+//		SetExt("shopId", "1001").
+//		AddPage("pages/index/index").
+//		SetPageWindow("pages/index/index", &alipay.TemplateWindow{DefaultTitle: "首页"})
+//	err := biz.SetExt(ext)
+type TemplateExt struct {
+	ExtEnable bool                       `json:"extEnable"`          // 是否启用ext配置
+	Ext       map[string]interface{}     `json:"ext,omitempty"`      // 自定义配置，小程序中通过my.getExtConfig获取
+	ExtPages  map[string]*TemplateWindow `json:"extPages,omitempty"` // 单个页面的窗口配置，key为页面路径
+	Pages     []string                   `json:"pages,omitempty"`    // 页面路径，第一个为首页
+	Window    *TemplateWindow            `json:"window,omitempty"`   // 全局窗口配置
+	TabBar    *TemplateTabBar            `json:"tabBar,omitempty"`   // 底部标签栏
+}
+
+// TemplateWindow 窗口配置
+type TemplateWindow struct {
+	DefaultTitle         string `json:"defaultTitle,omitempty"`         // 页面默认标题
+	PullRefresh          bool   `json:"pullRefresh,omitempty"`          // 是否允许下拉刷新
+	AllowsBounceVertical string `json:"allowsBounceVertical,omitempty"` // 页面是否支持纵向拽拉超出实际内容，YES或NO
+	TransparentTitle     string `json:"transparentTitle,omitempty"`     // 导航栏透明设置，always、auto或none
+	TitleBarColor        string `json:"titleBarColor,omitempty"`        // 导航栏背景色，#RRGGBB格式
+	BackgroundColor      string `json:"backgroundColor,omitempty"`      // 页面背景色，#RRGGBB格式
+}
+
+// TemplateTabBar 底部标签栏配置
+type TemplateTabBar struct {
+	TextColor       string                `json:"textColor,omitempty"`       // 文字颜色，#RRGGBB格式
+	SelectedColor   string                `json:"selectedColor,omitempty"`   // 选中文字颜色，#RRGGBB格式
+	BackgroundColor string                `json:"backgroundColor,omitempty"` // 背景色，#RRGGBB格式
+	Items           []*TemplateTabBarItem `json:"items"`                     // 标签，2到5个
+}
+
+// TemplateTabBarItem 底部标签
+type TemplateTabBarItem struct {
+	PagePath   string `json:"pagePath"`             // 页面路径，必须在pages中
+	Name       string `json:"name"`                 // 标签名称
+	Icon       string `json:"icon,omitempty"`       // 图标路径
+	ActiveIcon string `json:"activeIcon,omitempty"` // 选中时的图标路径
+}
+
+// NewTemplateExt 创建启用了ext配置的TemplateExt
+func NewTemplateExt() *TemplateExt {
+	return &TemplateExt{ExtEnable: true}
+}
+
+// ParseTemplateExt 解析ext.json配置
+func ParseTemplateExt(s string) (*TemplateExt, error) {
+	e := new(TemplateExt)
+	if err := json.Unmarshal([]byte(s), e); err != nil {
+		return nil, fmt.Errorf("alipay: invalid template ext: %w", err)
+	}
+	return e, nil
+}
+
+// SetExt 设置自定义配置
+func (e *TemplateExt) SetExt(key string, value interface{}) *TemplateExt {
+	if e.Ext == nil {
+		e.Ext = make(map[string]interface{})
+	}
+	e.Ext[key] = value
+	return e
+}
+
+// AddPage 添加页面，已存在的页面不会重复添加
+func (e *TemplateExt) AddPage(path string) *TemplateExt {
+	for _, p := range e.Pages {
+		if p == path {
+			return e
+		}
+	}
+	e.Pages = append(e.Pages, path)
+	return e
+}
+
+// SetPageWindow 设置单个页面的窗口配置
+func (e *TemplateExt) SetPageWindow(path string, window *TemplateWindow) *TemplateExt {
+	if e.ExtPages == nil {
+		e.ExtPages = make(map[string]*TemplateWindow)
+	}
+	e.ExtPages[path] = window
+	return e
+}
+
+// SetWindow 设置全局窗口配置
+func (e *TemplateExt) SetWindow(window *TemplateWindow) *TemplateExt {
+	e.Window = window
+	return e
+}
+
+// AddTabBarItem 添加底部标签
+func (e *TemplateExt) AddTabBarItem(item *TemplateTabBarItem) *TemplateExt {
+	if e.TabBar == nil {
+		e.TabBar = new(TemplateTabBar)
+	}
+	e.TabBar.Items = append(e.TabBar.Items, item)
+	return e
+}
+
+// String 返回序列化后的ext.json，用于UploadVersionBiz.Ext
+func (e *TemplateExt) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+var (
+	pagePathPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+(/[A-Za-z0-9_\-]+)*$`)
+	colorPattern    = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// Validate 校验配置结构和页面路径
+//
+// 页面路径是不以/开头、不带扩展名的相对路径，例如pages/index/index；
+// pages不为空时，extPages和tabBar引用的页面必须在pages中。
+func (e *TemplateExt) Validate() error {
+	v := newValidation(e)
+	pages := make(map[string]bool, len(e.Pages))
+	for i, p := range e.Pages {
+		field := fmt.Sprintf("pages[%d]", i)
+		v.pagePath(field, p)
+		v.check(!pages[p], field, "duplicate page %q", p)
+		pages[p] = true
+	}
+	inPages := func(field, path string) {
+		v.check(len(pages) == 0 || pages[path], field, "page %q is not in pages", path)
+	}
+	paths := make([]string, 0, len(e.ExtPages))
+	for path := range e.ExtPages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		window := e.ExtPages[path]
+		field := fmt.Sprintf("extPages[%v]", path)
+		if v.pagePath(field, path) {
+			inPages(field, path)
+		}
+		v.window(field, window)
+	}
+	if e.Window != nil {
+		v.window("window", e.Window)
+	}
+	if tabBar := e.TabBar; tabBar != nil {
+		v.color("tabBar.textColor", tabBar.TextColor)
+		v.color("tabBar.selectedColor", tabBar.SelectedColor)
+		v.color("tabBar.backgroundColor", tabBar.BackgroundColor)
+		n := len(tabBar.Items)
+		v.check(n >= 2 && n <= 5, "tabBar.items", "%d items out of range [2, 5]", n)
+		for i, item := range tabBar.Items {
+			field := fmt.Sprintf("tabBar.items[%d]", i)
+			if item == nil {
+				v.check(false, field, "is required")
+				continue
+			}
+			if v.pagePath(field+".pagePath", item.PagePath) {
+				inPages(field+".pagePath", item.PagePath)
+			}
+			v.required(field+".name", item.Name)
+		}
+	}
+	return v.err()
+}
+
+// pagePath 校验页面路径
+func (v *validation) pagePath(field, path string) bool {
+	if !v.required(field, path) {
+		return false
+	}
+	ok := pagePathPattern.MatchString(path)
+	v.check(ok, field, "%q is not a page path like pages/index/index", path)
+	return ok
+}
+
+// window 校验窗口配置
+func (v *validation) window(field string, w *TemplateWindow) {
+	if w == nil {
+		v.check(false, field, "is required")
+		return
+	}
+	v.oneOf(field+".allowsBounceVertical", w.AllowsBounceVertical, "YES", "NO")
+	v.oneOf(field+".transparentTitle", w.TransparentTitle, "always", "auto", "none")
+	v.color(field+".titleBarColor", w.TitleBarColor)
+	v.color(field+".backgroundColor", w.BackgroundColor)
+}
+
+// color 校验非空的#RRGGBB格式颜色
+func (v *validation) color(field, value string) {
+	if value == "" {
+		return
+	}
+	v.check(colorPattern.MatchString(value), field, "%q is not in #RRGGBB format", value)
+}
+
+// ext 校验UploadVersionBiz.Ext是合法的JSON，配置内容由TemplateExt.Validate校验
+func (v *validation) ext(field, value string) {
+	if value == "" {
+		return
+	}
+	v.check(json.Valid([]byte(value)), field, "is not valid JSON")
+}
+
+// SetExt 校验并序列化ext.json配置到Ext
+func (b *UploadVersionBiz) SetExt(ext *TemplateExt) error {
+	if err := ext.Validate(); err != nil {
+		return err
+	}
+	b.Ext = ext.String()
+	return nil
+}
@@ -0,0 +1,102 @@
+package alipay
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateExt(t *testing.T) {
+	ext := NewTemplateExt().
+		SetExt("shopId", "1001").
+		AddPage("pages/index/index").
+		AddPage("pages/mine/index").
+		AddPage("pages/index/index").
+		SetPageWindow("pages/index/index", &TemplateWindow{DefaultTitle: "首页"}).
+		SetWindow(&TemplateWindow{DefaultTitle: "小店", TitleBarColor: "#FFFFFF"}).
+		AddTabBarItem(&TemplateTabBarItem{PagePath: "pages/index/index", Name: "首页"}).
+		AddTabBarItem(&TemplateTabBarItem{PagePath: "pages/mine/index", Name: "我的"})
+	if err := ext.Validate(); err != nil {
+		t.Fatalf("TemplateExt.Validate returned unexcepted error: %v", err)
+	}
+
+	biz := &UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1"}
+	if err := biz.SetExt(ext); err != nil {
+		t.Fatalf("UploadVersionBiz.SetExt returned unexcepted error: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(biz.Ext), &got); err != nil {
+		t.Fatalf("Ext is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"extEnable": true,
+		"ext":       map[string]interface{}{"shopId": "1001"},
+		"extPages":  map[string]interface{}{"pages/index/index": map[string]interface{}{"defaultTitle": "首页"}},
+		"pages":     []interface{}{"pages/index/index", "pages/mine/index"},
+		"window":    map[string]interface{}{"defaultTitle": "小店", "titleBarColor": "#FFFFFF"},
+		"tabBar": map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{"pagePath": "pages/index/index", "name": "首页"},
+			map[string]interface{}{"pagePath": "pages/mine/index", "name": "我的"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ext got %v, want %v", got, want)
+	}
+	if err := biz.Validate(); err != nil {
+		t.Errorf("UploadVersionBiz.Validate returned unexcepted error: %v", err)
+	}
+
+	parsed, err := ParseTemplateExt(biz.Ext)
+	if err != nil || !reflect.DeepEqual(parsed, ext) {
+		t.Errorf("ParseTemplateExt got %+v, %v, want %+v", parsed, err, ext)
+	}
+}
+
+func TestTemplateExt_Validate(t *testing.T) {
+	ext := &TemplateExt{
+		ExtEnable: true,
+		Pages:     []string{"pages/index/index", "/pages/a", "pages/b.axml", "pages/index/index"},
+		ExtPages: map[string]*TemplateWindow{
+			"pages/other/index": {TransparentTitle: "sometimes"},
+		},
+		Window: &TemplateWindow{TitleBarColor: "white"},
+		TabBar: &TemplateTabBar{Items: []*TemplateTabBarItem{{PagePath: "pages/index/index"}}},
+	}
+	err := ext.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("TemplateExt.Validate got %v, want *ValidationError", err)
+	}
+	for _, field := range []string{
+		"pages[1]", "pages[2]", "pages[3]",
+		"extPages[pages/other/index]", "extPages[pages/other/index].transparentTitle",
+		"window.titleBarColor", "tabBar.items", "tabBar.items[0].name",
+	} {
+		if verr.Field(field) == nil {
+			t.Errorf("TemplateExt.Validate missing error for %v in %v", field, err)
+		}
+	}
+	if verr.Field("tabBar.items[0].pagePath") != nil {
+		t.Errorf("TemplateExt.Validate got error for valid tab bar page: %v", err)
+	}
+
+	biz := &UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1"}
+	if err := biz.SetExt(ext); err == nil || biz.Ext != "" {
+		t.Errorf("UploadVersionBiz.SetExt excepted error")
+	}
+}
+
+func TestUploadVersionBiz_Validate_ext(t *testing.T) {
+	biz := &UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1", Ext: `{"extEnable": true, "pages": [`}
+	var verr *ValidationError
+	if err := biz.Validate(); !errors.As(err, &verr) || verr.Field("ext") == nil {
+		t.Errorf("UploadVersionBiz.Validate got %v, want error for ext", err)
+	}
+
+	// 只校验JSON格式，配置内容由TemplateExt.Validate校验
+	biz.Ext = `{"extEnable": true, "extPages": {"/pages/index": {"defaultTitle": "首页"}}}`
+	if err := biz.Validate(); err != nil {
+		t.Errorf("UploadVersionBiz.Validate returned unexcepted error: %v", err)
+	}
+}
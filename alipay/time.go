@@ -0,0 +1,94 @@
+package alipay
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Location 支付宝网关使用的时区Asia/Shanghai
+//
+// 系统缺少时区数据时使用固定的UTC+8，中国自1991年起不再实行夏令时，两者等价。
+var Location = loadLocation()
+
+func loadLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}
+
+// dateLayout 部分接口只返回日期，例如成员的gmt_join
+const dateLayout = "2006-01-02"
+
+// Time 支付宝返回的时间，格式为2006-01-02 15:04:05，时区为Asia/Shanghai
+type Time struct {
+	time.Time
+}
+
+// ParseTime 解析支付宝时间格式，也接受只有日期的2006-01-02
+func ParseTime(s string) (Time, error) {
+	layout := timeLayout
+	if len(s) == len(dateLayout) {
+		layout = dateLayout
+	}
+	t, err := time.ParseInLocation(layout, s, Location)
+	if err != nil {
+		return Time{}, err
+	}
+	return Time{t}, nil
+}
+
+// String 按支付宝时间格式输出，零值为空字符串
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location).Format(timeLayout)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. 空字符串和null解析为零值
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*t = Time{}
+		return nil
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Time) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*t = Time{}
+		return nil
+	}
+	parsed, err := ParseTime(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
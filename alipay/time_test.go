@@ -0,0 +1,75 @@
+package alipay
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func mustParseTime(s string) Time {
+	t, err := ParseTime(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseTime(t *testing.T) {
+	got, err := ParseTime("2017-12-12 12:00:00")
+	if err != nil {
+		t.Fatalf("ParseTime returned unexcepted error: %v", err)
+	}
+	if want := time.Date(2017, 12, 12, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseTime got %v, want %v", got.UTC(), want)
+	}
+	if got.String() != "2017-12-12 12:00:00" {
+		t.Errorf("String got %v, want 2017-12-12 12:00:00", got.String())
+	}
+
+	date, err := ParseTime("2017-08-12")
+	if err != nil {
+		t.Fatalf("ParseTime returned unexcepted error: %v", err)
+	}
+	if date.String() != "2017-08-12 00:00:00" {
+		t.Errorf("ParseTime got %v, want 2017-08-12 00:00:00", date)
+	}
+	if _, err := ParseTime("2017/08/12"); err == nil {
+		t.Errorf("ParseTime excepted error")
+	}
+}
+
+func TestTime_JSON(t *testing.T) {
+	var v struct {
+		A Time `json:"a"`
+		B Time `json:"b"`
+		C Time `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"2017-09-08 12:00:00","b":"","c":null}`), &v); err != nil {
+		t.Fatalf("json.Unmarshal returned unexcepted error: %v", err)
+	}
+	if v.A.String() != "2017-09-08 12:00:00" || !v.B.IsZero() || !v.C.IsZero() {
+		t.Errorf("json.Unmarshal got %+v", v)
+	}
+	data, _ := json.Marshal(v)
+	if want := `{"a":"2017-09-08 12:00:00","b":"","c":""}`; string(data) != want {
+		t.Errorf("json.Marshal got %s, want %s", data, want)
+	}
+	if err := json.Unmarshal([]byte(`{"a":"yesterday"}`), &v); err == nil {
+		t.Errorf("json.Unmarshal excepted error")
+	}
+}
+
+func TestClient_NewRequest_timestamp(t *testing.T) {
+	c := NewClient(nil, nil, nil)
+	c.Clock = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+	req, err := c.NewRequest("alipay.open.mini.baseinfo.query", nil)
+	if err != nil {
+		t.Fatalf("NewRequest returned unexcepted error: %v", err)
+	}
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("ParseForm returned unexcepted error: %v", err)
+	}
+	if got := req.PostForm.Get("timestamp"); got != "2020-01-01 08:00:00" {
+		t.Errorf("timestamp got %v, want 2020-01-01 08:00:00", got)
+	}
+}
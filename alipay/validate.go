@@ -0,0 +1,142 @@
+package alipay
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator 可以在发送请求前校验参数的biz
+//
+// NewRequest会自动调用bizContent的Validate，设置Client.SkipValidation可以关闭。
+type Validator interface {
+	Validate() error
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string // 字段的json名称
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError biz参数校验错误，包含所有不合法的字段
+type ValidationError struct {
+	Biz    string // biz类型名称
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("alipay: invalid %v: %v", e.Biz, strings.Join(msgs, "; "))
+}
+
+// Field 返回字段的校验错误，字段合法时返回nil
+func (e *ValidationError) Field(name string) *FieldError {
+	for _, fe := range e.Errors {
+		if fe.Field == name {
+			return fe
+		}
+	}
+	return nil
+}
+
+var versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// validation 收集字段校验错误
+type validation struct {
+	biz    string
+	errors []*FieldError
+}
+
+func newValidation(biz interface{}) *validation {
+	return &validation{biz: strings.TrimPrefix(fmt.Sprintf("%T", biz), "*alipay.")}
+}
+
+func (v *validation) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.errors = append(v.errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validation) required(field, value string) bool {
+	v.check(value != "", field, "is required")
+	return value != ""
+}
+
+// length 校验非空字段的字符数
+func (v *validation) length(field, value string, min, max int) {
+	if value == "" {
+		return
+	}
+	n := utf8.RuneCountInString(value)
+	v.check(n >= min && n <= max, field, "length %d out of range [%d, %d]", n, min, max)
+}
+
+// maxLength 校验字段的最大字符数
+func (v *validation) maxLength(field, value string, max int) {
+	n := utf8.RuneCountInString(value)
+	v.check(n <= max, field, "length %d exceeds %d", n, max)
+}
+
+// oneOf 校验非空字段的取值
+func (v *validation) oneOf(field, value string, values ...string) {
+	if value == "" {
+		return
+	}
+	for _, s := range values {
+		if value == s {
+			return
+		}
+	}
+	v.check(false, field, "%q is not one of %v", value, strings.Join(values, ", "))
+}
+
+// version 校验非空字段满足x.y.z格式
+func (v *validation) version(field, value string) {
+	if value == "" {
+		return
+	}
+	v.check(versionPattern.MatchString(value), field, "%q is not in x.y.z format", value)
+}
+
+// categories 校验类目数量，类目之间使用;隔开
+func (v *validation) categories(field, value string, max int) {
+	if value == "" {
+		return
+	}
+	n := len(strings.Split(strings.Trim(value, ";"), ";"))
+	v.check(n <= max, field, "%d categories exceeds %d", n, max)
+}
+
+func (v *validation) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Biz: v.biz, Errors: v.errors}
+}
+
+// validateAppVersion 校验必选的小程序版本号
+func validateAppVersion(biz interface{}, appVersion string) error {
+	v := newValidation(biz)
+	if v.required("app_version", appVersion) {
+		v.version("app_version", appVersion)
+	}
+	return v.err()
+}
+
+// memberRoles 小程序成员角色
+var memberRoles = []string{string(MemberRoleDeveloper), string(MemberRoleExperiencer)}
+
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
@@ -0,0 +1,89 @@
+package alipay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		biz    Validator
+		fields []string
+	}{
+		{"valid upload", &UploadVersionBiz{AppVersion: "0.0.1", TemplateID: "1", TemplateVersion: "1.2.3"}, nil},
+		{"upload", &UploadVersionBiz{AppVersion: "1.0", TemplateVersion: "v1"}, []string{"app_version", "template_id", "template_version"}},
+		{"app desc", &ModifyBaseInfoBiz{AppDesc: "太短了"}, []string{"app_desc"}},
+		{"categories", &ModifyBaseInfoBiz{MiniCategoryIDs: "1_2;3_4;5_6;7_8;9_10"}, []string{"mini_category_ids"}},
+		{"gray strategy", &OnlineGrayVersionBiz{AppVersion: "0.0.1", GrayStrategy: "p20"}, []string{"gray_strategy"}},
+		{"role", &CreateAppMemberBiz{LogonID: "test_id", Role: "OWNER"}, []string{"role"}},
+		{"query role", &QueryAppMembersBiz{}, nil},
+		{"risk content", &DetectRiskContentBiz{Content: strings.Repeat("字", 2001)}, []string{"content"}},
+		{"region", &ApplyVersionAuditBiz{AppVersion: "0.0.1", VersionDesc: "描述", RegionType: "LOCATION"}, []string{"service_region_info"}},
+		{"region type", &ApplyVersionAuditBiz{AppVersion: "0.0.1", VersionDesc: "描述", RegionType: "CITY"}, []string{"region_type"}},
+		{"qr code", &BindQrCodeBiz{Mode: "ALL"}, []string{"route_url", "mode", "page_redirection"}},
+		{"page size", &QueryTemplateUsageBiz{TemplateID: "1", PageSize: 51}, []string{"page_size"}},
+		{"safe domain", &CreateSafeDomainBiz{}, []string{"safe_domain"}},
+		{"cancel audit", &CancelVersionAuditBiz{}, nil},
+		{"app version", &OnlineVersionBiz{}, []string{"app_version"}},
+	}
+	for _, tt := range tests {
+		err := tt.biz.Validate()
+		if len(tt.fields) == 0 {
+			if err != nil {
+				t.Errorf("%v: Validate returned unexcepted error: %v", tt.name, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%v: Validate got %v, want *ValidationError", tt.name, err)
+			continue
+		}
+		if len(verr.Errors) != len(tt.fields) {
+			t.Errorf("%v: Validate got %v, want fields %v", tt.name, err, tt.fields)
+		}
+		for _, f := range tt.fields {
+			if verr.Field(f) == nil {
+				t.Errorf("%v: Validate got %v, want error on %v", tt.name, err, f)
+			}
+		}
+	}
+}
+
+func TestClient_NewRequest_validation(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	var called bool
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte(`{"alipay_open_mini_version_gray_online_response":{"code":"10000","msg":"Success"}}`))
+	})
+
+	biz := &OnlineGrayVersionBiz{AppVersion: "0.0.1", GrayStrategy: "p20"}
+	err := client.Mini.OnlineGrayVersion(context.Background(), biz)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Biz != "OnlineGrayVersionBiz" {
+		t.Errorf("Mini.OnlineGrayVersion got %v, want *ValidationError", err)
+	}
+	if called {
+		t.Errorf("invalid request should not be sent")
+	}
+
+	client.SkipValidation = true
+	if err := client.Mini.OnlineGrayVersion(context.Background(), biz); err != nil {
+		t.Errorf("Mini.OnlineGrayVersion returned unexcepted error: %v", err)
+	}
+	if !called {
+		t.Errorf("request should be sent when validation is skipped")
+	}
+
+	client.SkipValidation = false
+	if _, err := client.NewRequest("alipay.open.mini.version.online", (*OnlineVersionBiz)(nil)); err != nil {
+		t.Errorf("NewRequest returned unexcepted error for nil biz: %v", err)
+	}
+}
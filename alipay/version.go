@@ -0,0 +1,131 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version 小程序版本号，格式为x.y.z，均为数字
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion 解析x.y.z格式的版本号，每段为不带符号的十进制数字，除0以外不能以0开头
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("alipay: invalid version %q", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		if p == "" || strings.Trim(p, "0123456789") != "" || (len(p) > 1 && p[0] == '0') {
+			return Version{}, fmt.Errorf("alipay: invalid version %q", s)
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Version{}, fmt.Errorf("alipay: invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// String 返回x.y.z格式的版本号
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare 比较两个版本号，v小于、等于、大于o时分别返回-1、0、1
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return compareInt(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return compareInt(v.Minor, o.Minor)
+	default:
+		return compareInt(v.Patch, o.Patch)
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Less 是否小于o
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// VersionBump 版本号递增的位置
+type VersionBump int
+
+// 版本号递增的位置
+const (
+	BumpPatch VersionBump = iota // 递增z
+	BumpMinor                    // 递增y，z归零
+	BumpMajor                    // 递增x，y和z归零
+)
+
+// Bump 返回递增后的版本号
+func (v Version) Bump(bump VersionBump) Version {
+	switch bump {
+	case BumpMajor:
+		return Version{Major: v.Major + 1}
+	case BumpMinor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// Sorted 返回从小到大排序的版本号，忽略无法解析的版本
+func (r *QueryVersionListResp) Sorted() []Version {
+	versions := make([]Version, 0, len(r.AppVersions))
+	for _, s := range r.AppVersions {
+		if v, err := ParseVersion(s); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Less(versions[j]) })
+	return versions
+}
+
+// Latest 返回最大的版本号，没有版本时返回false
+func (r *QueryVersionListResp) Latest() (Version, bool) {
+	versions := r.Sorted()
+	if len(versions) == 0 {
+		return Version{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Next 在最大的版本号上递增，没有版本时从0.0.0递增
+func (r *QueryVersionListResp) Next(bump VersionBump) Version {
+	latest, _ := r.Latest()
+	return latest.Bump(bump)
+}
+
+// NextVersion 查询已有版本，返回可以用于UploadVersion的下一个版本号
+func (s *MiniService) NextVersion(ctx context.Context, bump VersionBump, opts ...ValueOptions) (Version, error) {
+	return NextVersion(ctx, s, bump, opts...)
+}
+
+// NextVersion 通过mini查询已有版本，返回可以用于UploadVersion的下一个版本号
+func NextVersion(ctx context.Context, mini MiniAPI, bump VersionBump, opts ...ValueOptions) (Version, error) {
+	resp, err := mini.QueryVersionList(ctx, opts...)
+	if err != nil {
+		return Version{}, err
+	}
+	return resp.Next(bump), nil
+}
@@ -0,0 +1,123 @@
+package alipay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("1.10.3")
+	if err != nil {
+		t.Fatalf("ParseVersion returned unexcepted error: %v", err)
+	}
+	if want := (Version{1, 10, 3}); v != want || v.String() != "1.10.3" {
+		t.Errorf("ParseVersion got %v, want %v", v, want)
+	}
+	for _, s := range []string{"0.0.0", "0.10.0", "10.0.100"} {
+		v, err := ParseVersion(s)
+		if err != nil || v.String() != s {
+			t.Errorf("ParseVersion(%q) got %v, %v, want round trip", s, v, err)
+		}
+	}
+	for _, s := range []string{"", "1.0", "1.0.0.0", "1.a.0", "1.-1.0", "1.+1.0", "v1.0.0",
+		"-0.1.0", "+1.0.0", "01.2.3", "1.00.0", "1..0", "1.0. 1", "99999999999999999999.0.0"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) excepted error", s)
+		}
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.0.1", "0.0.1", 0},
+		{"0.0.2", "0.0.10", -1},
+		{"0.2.0", "0.1.9", 1},
+		{"2.0.0", "10.0.0", -1},
+	}
+	for _, tt := range tests {
+		a, _ := ParseVersion(tt.a)
+		b, _ := ParseVersion(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%v.Compare(%v) got %v, want %v", a, b, got, tt.want)
+		}
+	}
+}
+
+func TestVersion_Bump(t *testing.T) {
+	v := Version{1, 2, 3}
+	if got := v.Bump(BumpPatch).String(); got != "1.2.4" {
+		t.Errorf("Bump(BumpPatch) got %v, want 1.2.4", got)
+	}
+	if got := v.Bump(BumpMinor).String(); got != "1.3.0" {
+		t.Errorf("Bump(BumpMinor) got %v, want 1.3.0", got)
+	}
+	if got := v.Bump(BumpMajor).String(); got != "2.0.0" {
+		t.Errorf("Bump(BumpMajor) got %v, want 2.0.0", got)
+	}
+}
+
+func TestQueryVersionListResp_Sorted(t *testing.T) {
+	resp := &QueryVersionListResp{AppVersions: []string{"0.0.10", "0.0.2", "invalid", "0.1.0"}}
+	want := []Version{{0, 0, 2}, {0, 0, 10}, {0, 1, 0}}
+	if got := resp.Sorted(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sorted got %v, want %v", got, want)
+	}
+	if latest, ok := resp.Latest(); !ok || latest != (Version{0, 1, 0}) {
+		t.Errorf("Latest got %v %v, want 0.1.0", latest, ok)
+	}
+	if _, ok := new(QueryVersionListResp).Latest(); ok {
+		t.Errorf("Latest got true for empty list")
+	}
+	if got := new(QueryVersionListResp).Next(BumpPatch).String(); got != "0.0.1" {
+		t.Errorf("Next got %v, want 0.0.1", got)
+	}
+}
+
+func TestMiniService_NextVersion(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "10000",
+								"msg": "Success",
+								"app_versions": ["0.0.9", "0.0.10", "0.0.3"]
+							}
+						}`)
+	})
+
+	got, err := client.Mini.NextVersion(context.Background(), BumpPatch)
+	if err != nil {
+		t.Errorf("Mini.NextVersion returned unexcepted error: %v", err)
+	}
+	if got.String() != "0.0.11" {
+		t.Errorf("Mini.NextVersion got %v, want 0.0.11", got)
+	}
+}
+
+func TestMiniService_NextVersion_failed(t *testing.T) {
+	client, mux, _, tearDown := setup()
+	defer tearDown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+							"alipay_open_mini_version_list_query_response": {
+								"code": "20000",
+								"msg": "Service Currently Unavailable",
+								"sub_code": "isp.unknow-error",
+								"sub_msg": "系统繁忙"
+							}
+						}`)
+	})
+
+	if _, err := client.Mini.NextVersion(context.Background(), BumpPatch); err == nil {
+		t.Errorf("Mini.NextVersion excepted error")
+	}
+}